@@ -0,0 +1,529 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/lexer"
+	"github.com/IfanTsai/jirachi/parser"
+	"github.com/IfanTsai/jirachi/token"
+)
+
+// signature is type annotations of function, empty string means the type isn't annotated
+type signature struct {
+	argNames   []string
+	argTypes   []string
+	returnType string
+}
+
+// scope holds types inferred for variables of a function or program
+type scope struct {
+	types      map[any]string
+	annotated  map[any]string // variables with type annotation keep their annotated type
+	signatures map[any]*signature
+	parent     *scope
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{
+		types:      make(map[any]string),
+		annotated:  make(map[any]string),
+		signatures: make(map[any]*signature),
+		parent:     parent,
+	}
+}
+
+func (s *scope) lookup(name any) (string, *signature) {
+	for current := s; current != nil; current = current.parent {
+		if varType, ok := current.types[name]; ok {
+			return varType, current.signatures[name]
+		}
+	}
+
+	return object.Any, nil
+}
+
+func (s *scope) annotation(name any) string {
+	for current := s; current != nil; current = current.parent {
+		if _, ok := current.types[name]; ok {
+			return current.annotated[name]
+		}
+	}
+
+	return ""
+}
+
+// JChecker infers types through AST and reports mismatches with type annotations before running.
+// Types which cannot be inferred are any, which matches all annotations
+type JChecker struct {
+	scope      *scope
+	funcName   any    // name of the function being checked
+	returnType string // annotated return type of the function being checked
+	Errors     []error
+}
+
+func NewJChecker() *JChecker {
+	return &JChecker{
+		scope: newScope(nil),
+	}
+}
+
+// Check checks source text, the returned error is set if the text cannot be parsed
+func Check(filename, text string) ([]error, error) {
+	tokens, err := lexer.NewJLexer(filename, text).MakeTokens()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to make tokens")
+	}
+
+	ast, err := parser.NewJParser(tokens, -1).Parse()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse tokens")
+	}
+
+	checker := NewJChecker()
+	checker.infer(ast)
+
+	return checker.Errors, nil
+}
+
+// returnTypes of built-in functions
+var returnTypes = map[string]string{
+	"len":         object.Number,
+	"type":        object.String,
+	"input":       object.String,
+	"keys":        object.List,
+	"values":      object.List,
+	"items":       object.List,
+	"to_list":     object.List,
+	"range":       object.Range,
+	"iter":        object.Iterator,
+	"map":         object.Iterator,
+	"filter":      object.Iterator,
+	"encode":      object.Bytes,
+	"decode":      object.String,
+	"to_hex":      object.String,
+	"from_hex":    object.Bytes,
+	"to_base64":   object.String,
+	"from_base64": object.Bytes,
+	"read_bytes":  object.Bytes,
+	"error":       object.Error,
+	"dir":         object.List,
+	"params":      object.List,
+	"arity":       object.Number,
+	"callable":    object.Number,
+	"source":      object.String,
+	"globals":     object.Map,
+	"locals":      object.Map,
+	"ast_var":     object.Ast,
+	"ast_assign":  object.Ast,
+	"ast_call":    object.Ast,
+	"ast_block":   object.Ast,
+	"run_shell":   object.Map,
+	"run_cmd":     object.Map,
+}
+
+func (c *JChecker) infer(node parser.JNode) string {
+	if node == nil {
+		return object.Any
+	}
+
+	switch node := node.(type) {
+	case *parser.JNumberNode:
+		return object.Number
+	case *parser.JStringNode:
+		return object.String
+	case *parser.JBytesNode:
+		return object.Bytes
+	case *parser.JListNode:
+		resType := object.Any
+		for _, elementNode := range node.ElementNodes {
+			resType = c.infer(elementNode)
+		}
+
+		if node.IsBlockStatements {
+			return resType
+		}
+
+		return object.List
+	case *parser.JMapNode:
+		for _, entry := range node.KeyValueNodes {
+			c.infer(entry[0])
+			c.infer(entry[1])
+		}
+
+		return object.Map
+	case *parser.JTupleNode:
+		for _, elementNode := range node.ElementNodes {
+			c.infer(elementNode)
+		}
+
+		return object.Tuple
+	case *parser.JListCompNode:
+		return object.List
+	case *parser.JMapCompNode:
+		return object.Map
+	case *parser.JVarAccessNode:
+		varType, _ := c.scope.lookup(node.Token.Value)
+
+		return varType
+	case *parser.JVarAssignNode:
+		return c.inferAssign(node.Token, node.TypeToken, node.Node)
+	case *parser.JVarDeclareNode:
+		return c.inferAssign(node.VarToken, node.TypeToken, node.Node)
+	case *parser.JVarUnpackAssignNode:
+		c.infer(node.Node)
+
+		for _, varToken := range node.VarTokens {
+			c.scope.types[varToken.Value] = object.Any
+		}
+
+		return object.Any
+	case *parser.JBinOpNode:
+		return c.inferBinOp(node)
+	case *parser.JUnaryOpNode:
+		c.infer(node.Node)
+
+		return object.Number
+	case *parser.JIfExprNode:
+		return c.inferIf(node)
+	case *parser.JForExprNode:
+		c.infer(node.StartValueNode)
+		c.infer(node.EndValueNode)
+		c.infer(node.StepValueNode)
+		c.scope.types[node.Token.Value] = object.Number
+		c.infer(node.BodyNode)
+		c.infer(node.ElseNode)
+
+		return object.Any
+	case *parser.JForInExprNode:
+		elementType := object.Any
+		switch c.infer(node.IterableNode) {
+		case object.Range, object.Bytes:
+			elementType = object.Number
+		case object.String:
+			elementType = object.String
+		}
+
+		c.scope.types[node.Token.Value] = elementType
+		c.infer(node.BodyNode)
+		c.infer(node.ElseNode)
+
+		return object.Any
+	case *parser.JWhileExprNode:
+		c.infer(node.ConditionNode)
+		c.infer(node.BodyNode)
+		c.infer(node.ElseNode)
+
+		return object.Any
+	case *parser.JFuncDefNode:
+		return c.inferFuncDef(node)
+	case *parser.JCallExprNode:
+		return c.inferCall(node)
+	case *parser.JPipeExprNode:
+		return c.inferCall(node.CallNode)
+	case *parser.JIndexExprNode:
+		c.infer(node.IndexNode)
+		c.infer(node.IndexExpr)
+
+		return object.Any
+	case *parser.JMemberAccessNode:
+		c.infer(node.Node)
+
+		return object.Any
+	case *parser.JSliceExprNode:
+		c.infer(node.SliceNode)
+		c.infer(node.StartExpr)
+		c.infer(node.EndExpr)
+
+		return object.Any
+	case *parser.JVarIndexAssignNode:
+		c.infer(node.IndexExprNode)
+		c.infer(node.Node)
+
+		return object.Any
+	case *parser.JReturnNode:
+		returnType := c.infer(node.ReturnNode)
+		if node.ReturnNode != nil {
+			c.checkReturnType(node.ReturnNode, returnType)
+		}
+
+		return returnType
+	case *parser.JDeferNode:
+		c.infer(node.DeferNode)
+
+		return object.Any
+	case *parser.JEnumDefNode:
+		for _, valueNode := range node.ValueNodes {
+			c.infer(valueNode)
+		}
+
+		c.scope.types[node.Token.Value] = object.Enum
+
+		return object.Enum
+	case *parser.JTraitDefNode:
+		c.scope.types[node.Token.Value] = object.Trait
+
+		return object.Trait
+	case *parser.JQuoteNode:
+		// the quoted node is code of the caller of macro, which isn't checked
+		return object.Ast
+	case *parser.JShellNode:
+		for _, valueNode := range node.ValueNodes {
+			c.infer(valueNode)
+		}
+
+		return object.Any
+	case *parser.JImplDefNode:
+		// methods are not variables of the current scope
+		outerScope := c.scope
+		c.scope = newScope(outerScope)
+
+		for _, methodNode := range node.MethodNodes {
+			c.inferFuncDef(methodNode)
+		}
+
+		c.scope = outerScope
+
+		return object.Trait
+	}
+
+	return object.Any
+}
+
+func (c *JChecker) inferAssign(varToken, typeToken *token.JToken, valueNode parser.JNode) string {
+	valueType := c.infer(valueNode)
+
+	annotation := c.scope.annotation(varToken.Value)
+	if typeToken != nil {
+		annotation = typeToken.Value.(string)
+		c.scope.annotated[varToken.Value] = annotation
+	}
+
+	if annotation != "" && !matches(annotation, valueType) {
+		c.addError(valueNode, fmt.Sprintf("variable '%v' must be %s, got %s", varToken.Value, annotation, valueType))
+	}
+
+	if annotation != "" && valueType == object.Any {
+		valueType = annotation
+	}
+
+	c.scope.types[varToken.Value] = valueType
+
+	return valueType
+}
+
+func (c *JChecker) inferBinOp(node *parser.JBinOpNode) string {
+	leftType := c.infer(node.LeftNode)
+	rightType := c.infer(node.RightNode)
+
+	switch node.Token.Type {
+	case token.PLUS:
+		if leftType == object.List || (leftType == rightType && leftType != object.Number) {
+			return leftType
+		}
+
+		fallthrough
+	case token.MINUS, token.MUL, token.DIV, token.POW:
+		if leftType == object.Number && rightType == object.Number {
+			return object.Number
+		}
+
+		if node.Token.Type == token.MUL && leftType == object.String && rightType == object.Number {
+			return object.String
+		}
+
+		return object.Any
+	case token.EE, token.NE, token.LT, token.LTE, token.GT, token.GTE:
+		return object.Number
+	case token.DOTDOT:
+		return object.Range
+	case token.KEYWORD:
+		if node.Token.Value != token.AND && node.Token.Value != token.OR {
+			return object.Number
+		}
+	}
+
+	return object.Any
+}
+
+// inferIf returns type of branches if all of them have the same type
+func (c *JChecker) inferIf(node *parser.JIfExprNode) string {
+	resType := ""
+
+	merge := func(branchType string) {
+		if resType == "" || resType == branchType {
+			resType = branchType
+		} else {
+			resType = object.Any
+		}
+	}
+
+	for _, caseNode := range node.CaseNodes {
+		c.infer(caseNode[0])
+		merge(c.infer(caseNode[1]))
+	}
+
+	if node.ElseCaseNode == nil {
+		return object.Any
+	}
+
+	merge(c.infer(node.ElseCaseNode))
+
+	return resType
+}
+
+func (c *JChecker) inferFuncDef(node *parser.JFuncDefNode) string {
+	sig := &signature{
+		argNames: make([]string, len(node.ArgTokens)),
+		argTypes: make([]string, len(node.ArgTokens)),
+	}
+
+	for index, argToken := range node.ArgTokens {
+		sig.argNames[index] = argToken.Value.(string)
+		if node.ArgTypeTokens[index] != nil {
+			sig.argTypes[index] = node.ArgTypeTokens[index].Value.(string)
+		}
+	}
+
+	if node.ReturnTypeToken != nil {
+		sig.returnType = node.ReturnTypeToken.Value.(string)
+	}
+
+	// declare function before checking body, so that recursive calls are checked
+	if node.Token != nil {
+		c.scope.types[node.Token.Value] = object.Function
+		c.scope.signatures[node.Token.Value] = sig
+	}
+
+	funcName := any("<anonymous>")
+	if node.Token != nil {
+		funcName = node.Token.Value
+	}
+
+	outerScope, outerFuncName, outerReturnType := c.scope, c.funcName, c.returnType
+	c.scope, c.funcName, c.returnType = newScope(outerScope), funcName, sig.returnType
+
+	for index, argName := range sig.argNames {
+		c.scope.types[argName] = object.Any
+		if sig.argTypes[index] != "" {
+			c.scope.types[argName] = sig.argTypes[index]
+			c.scope.annotated[argName] = sig.argTypes[index]
+		}
+	}
+
+	bodyType := c.infer(node.BodyNode)
+
+	// return statements are checked by themselves, otherwise the value of body is returned
+	if resultNode := implicitResultNode(node.BodyNode); resultNode != nil {
+		c.checkReturnType(resultNode, bodyType)
+	}
+
+	c.scope, c.funcName, c.returnType = outerScope, outerFuncName, outerReturnType
+
+	// decorators may return any value, so signature of decorated function is unknown
+	if len(node.DecoratorNodes) > 0 {
+		for _, decoratorNode := range node.DecoratorNodes {
+			c.infer(decoratorNode)
+		}
+
+		c.scope.types[node.Token.Value] = object.Any
+		delete(c.scope.signatures, node.Token.Value)
+
+		return object.Any
+	}
+
+	return object.Function
+}
+
+// implicitResultNode returns arrow function body or the last statement of block body, whose value
+// is the result of function, nil if the last statement is return statement
+func implicitResultNode(bodyNode parser.JNode) parser.JNode {
+	resultNode := bodyNode
+	if listNode, ok := bodyNode.(*parser.JListNode); ok && listNode.IsBlockStatements {
+		if len(listNode.ElementNodes) == 0 {
+			return nil
+		}
+
+		resultNode = listNode.ElementNodes[len(listNode.ElementNodes)-1]
+	}
+
+	if _, ok := resultNode.(*parser.JReturnNode); ok {
+		return nil
+	}
+
+	return resultNode
+}
+
+func (c *JChecker) inferCall(node *parser.JCallExprNode) string {
+	calleeType := c.infer(node.CallNode)
+
+	argTypes := make([]string, len(node.ArgNodes))
+	for index, argNode := range node.ArgNodes {
+		argTypes[index] = c.infer(argNode)
+	}
+
+	varAccessNode, ok := node.CallNode.(*parser.JVarAccessNode)
+	if !ok {
+		return object.Any
+	}
+
+	funcName := varAccessNode.Token.Value
+	_, sig := c.scope.lookup(funcName)
+
+	if sig == nil {
+		if calleeType == object.Any {
+			if returnType, ok := returnTypes[funcName.(string)]; ok {
+				return returnType
+			}
+		}
+
+		return object.Any
+	}
+
+	if len(node.ArgNodes) != len(sig.argNames) {
+		c.addError(node, fmt.Sprintf("%v takes %d arguments, got %d", funcName, len(sig.argNames), len(node.ArgNodes)))
+
+		return returnTypeOf(sig)
+	}
+
+	for index, argType := range argTypes {
+		if sig.argTypes[index] != "" && !matches(sig.argTypes[index], argType) {
+			c.addError(node.ArgNodes[index], fmt.Sprintf("argument '%s' of %v must be %s, got %s",
+				sig.argNames[index], funcName, sig.argTypes[index], argType))
+		}
+	}
+
+	return returnTypeOf(sig)
+}
+
+func (c *JChecker) checkReturnType(node parser.JNode, returnType string) {
+	if c.returnType != "" && !matches(c.returnType, returnType) {
+		c.addError(node, fmt.Sprintf("return value of %v must be %s, got %s", c.funcName, c.returnType, returnType))
+	}
+}
+
+func returnTypeOf(sig *signature) string {
+	if sig.returnType == "" {
+		return object.Any
+	}
+
+	return sig.returnType
+}
+
+func (c *JChecker) addError(node parser.JNode, details string) {
+	c.Errors = append(c.Errors, &common.JTypeCheckError{
+		JError: &common.JError{
+			StartPos: node.GetStartPos(),
+			EndPos:   node.GetEndPos(),
+		},
+		Details: details,
+	})
+}
+
+// matches reports whether inferred type matches annotation, type which cannot be inferred is assumed to match
+func matches(expected, actual string) bool {
+	return actual == object.Any || object.TypeMatches(expected, actual)
+}
@@ -0,0 +1,111 @@
+package checker_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/IfanTsai/jirachi/checker"
+	"github.com/IfanTsai/jirachi/common"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name        string
+		text        string
+		checkResult func(t *testing.T, typeErrors []error, err error)
+	}{
+		{
+			name: "OK",
+			text: `
+				fun add(a: number, b: number) -> number
+					return a + b
+				end
+
+				x: list = [1] + add(1, 2)
+				s: string = "a" + "b"
+				n: number = len(x)
+				f: function = add
+				fun untyped(a) -> a
+				add(untyped(1), 2)
+			`,
+			checkResult: func(t *testing.T, typeErrors []error, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.Empty(t, typeErrors)
+			},
+		},
+		{
+			name: "argument",
+			text: "fun add(a: number, b: number) -> a + b\nadd(1, \"a\")",
+			checkResult: func(t *testing.T, typeErrors []error, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.Len(t, typeErrors, 1)
+				require.IsType(t, &common.JTypeCheckError{}, typeErrors[0])
+				require.Contains(t, typeErrors[0].Error(), "Type Error: argument 'b' of add must be number, got string")
+				require.Contains(t, typeErrors[0].Error(), "line 1, col 8")
+			},
+		},
+		{
+			name: "argument count",
+			text: "fun add(a: number, b: number) -> a + b\nadd(1)",
+			checkResult: func(t *testing.T, typeErrors []error, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.Len(t, typeErrors, 1)
+				require.Contains(t, typeErrors[0].Error(), "add takes 2 arguments, got 1")
+			},
+		},
+		{
+			name: "return value",
+			text: "fun name(n: number) -> string\nif n then return 'a'\nreturn n\nend",
+			checkResult: func(t *testing.T, typeErrors []error, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.Len(t, typeErrors, 1)
+				require.Contains(t, typeErrors[0].Error(), "return value of name must be string, got number")
+			},
+		},
+		{
+			name: "implicit return value",
+			text: "fun bad(a: string) -> number\na\nend\nfun good(a: number) -> number\nif a then return 1\na + 1\nend",
+			checkResult: func(t *testing.T, typeErrors []error, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.Len(t, typeErrors, 1)
+				require.Contains(t, typeErrors[0].Error(), "return value of bad must be number, got string")
+			},
+		},
+		{
+			name: "variable",
+			text: "x: list = []\nx = 1\ny = 'a'\nz: number = y",
+			checkResult: func(t *testing.T, typeErrors []error, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.Len(t, typeErrors, 2)
+				require.Contains(t, typeErrors[0].Error(), "variable 'x' must be list, got number")
+				require.Contains(t, typeErrors[1].Error(), "variable 'z' must be number, got string")
+			},
+		},
+		{
+			name: "invalid syntax",
+			text: "x: = 1",
+			checkResult: func(t *testing.T, typeErrors []error, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Nil(t, typeErrors)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			typeErrors, err := checker.Check("stdin", testCase.text)
+			testCase.checkResult(t, typeErrors, err)
+		})
+	}
+}
@@ -7,34 +7,79 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/IfanTsai/jirachi/checker"
 	"github.com/IfanTsai/jirachi/repl"
 
 	"github.com/IfanTsai/jirachi/interpreter"
 )
 
 func main() {
-	if len(os.Args) == 1 {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "--checked" {
+		interpreter.CheckTypes = true
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
 		repl.Run()
+	} else if args[0] == "check" && len(args) > 1 {
+		check(args[1:])
 	} else {
-		filename := os.Args[1]
-		scriptFile, err := os.Open(filename)
+		filename := args[0]
+		text := readScript(filename)
+
+		_, err := interpreter.Run(filename, text)
 		if err != nil {
-			panic(err)
+			printError(err)
 		}
 
-		bytes, err := io.ReadAll(scriptFile)
+	}
+}
+
+// check checks type annotations of scripts without running them, exit with 1 if any mismatch is found
+func check(filenames []string) {
+	failed := false
+
+	for _, filename := range filenames {
+		typeErrors, err := checker.Check(filename, readScript(filename))
 		if err != nil {
-			panic(err)
+			printError(err)
+
+			failed = true
+
+			continue
 		}
 
-		_, err = interpreter.Run(filename, string(bytes))
-		if err != nil {
-			if repl.Release == "true" {
-				fmt.Printf("%v", errors.Cause(err))
-			} else {
-				fmt.Printf("%+v", err)
-			}
+		for _, typeError := range typeErrors {
+			fmt.Printf("%v\n", typeError)
 		}
 
+		failed = failed || len(typeErrors) > 0
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func readScript(filename string) string {
+	scriptFile, err := os.Open(filename)
+	if err != nil {
+		panic(err)
+	}
+
+	bytes, err := io.ReadAll(scriptFile)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(bytes)
+}
+
+func printError(err error) {
+	if repl.Release == "true" {
+		fmt.Printf("%v", errors.Cause(err))
+	} else {
+		fmt.Printf("%+v", err)
 	}
 }
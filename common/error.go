@@ -11,6 +11,10 @@ type JError struct {
 }
 
 func (e *JError) ErrorString(name, details string) string {
+	if e.StartPos == nil {
+		return fmt.Sprintf("%s: %s\n", name, details)
+	}
+
 	return fmt.Sprintf("%s: %s\nFile <%s>, line %d, col %d\n\n%s",
 		name, details,
 		e.StartPos.Filename, e.StartPos.Ln, e.StartPos.Col+1,
@@ -45,23 +49,63 @@ func (e *JInvalidSyntaxError) Error() string {
 	return e.ErrorString("Invalid Syntax", e.Details)
 }
 
+// JTypeCheckError is mismatch with type annotation found by static checker
+type JTypeCheckError struct {
+	*JError
+	Details string
+}
+
+func (e *JTypeCheckError) Error() string {
+	return e.ErrorString("Type Error", e.Details)
+}
+
+// kinds of runtime error, they are exposed to scripts by error values
+const (
+	RuntimeError      = "RuntimeError"
+	TypeError         = "TypeError"
+	ValueError        = "ValueError"
+	IndexError        = "IndexError"
+	NameError         = "NameError"
+	ZeroDivisionError = "ZeroDivisionError"
+	IOError           = "IOError"
+	StopIteration     = "StopIteration"
+	SyntaxError       = "SyntaxError"
+)
+
 type JRunTimeError struct {
 	*JError
 	Context *JContext
+	Kind    string // empty means RuntimeError
 	Details string
 }
 
 func (e *JRunTimeError) Error() string {
-	return e.generateTraceBack() + e.ErrorString("Runtime Error", e.Details)
+	return e.Traceback() + e.ErrorString("Runtime Error", e.Details)
+}
+
+func (e *JRunTimeError) GetKind() string {
+	if e.Kind == "" {
+		return RuntimeError
+	}
+
+	return e.Kind
 }
 
-func (e *JRunTimeError) generateTraceBack() string {
-	pos := e.StartPos
-	context := e.Context
+func (e *JRunTimeError) Traceback() string {
+	return GenerateTraceBack(e.StartPos, e.Context)
+}
+
+// GenerateTraceBack returns the calls from program to pos in context, pos may be nil if it is unknown
+func GenerateTraceBack(pos *JPosition, context *JContext) string {
 	result := ""
 
 	for context != nil {
-		result = fmt.Sprintf("  File %s, line %d, in %s\n", pos.Filename, pos.Ln+1, context.Name) + result
+		location := "<unknown>"
+		if pos != nil {
+			location = fmt.Sprintf("%s, line %d", pos.Filename, pos.Ln+1)
+		}
+
+		result = fmt.Sprintf("  File %s, in %s\n", location, context.Name) + result
 		pos = context.ParentEntryPos
 		context = context.Parent
 	}
@@ -5,13 +5,32 @@ import "github.com/IfanTsai/jirachi/pkg/safemap"
 type JSymbolTable struct {
 	Symbols *safemap.SafeMap[any]
 	Parent  *JSymbolTable
+	IsBlock bool                  // block of if or loop body, plain assignment doesn't create variable in it
+	Consts  map[any]struct{}      // names which cannot be reassigned
+	Outers  map[any]*JSymbolTable // names declared by global or nonlocal with their symbol table
+	// Registry holds definitions of program which aren't variables, eg. macros, keyed by types of interpreter.
+	// It is only created for symbol table of program, see Global
+	Registry *safemap.SafeMap[any]
 }
 
 func NewJSymbolTable(parent *JSymbolTable) *JSymbolTable {
-	return &JSymbolTable{
+	symbolTable := &JSymbolTable{
 		Symbols: safemap.NewSafeMap[any](),
 		Parent:  parent,
 	}
+
+	if parent == nil {
+		symbolTable.Registry = safemap.NewSafeMap[any]()
+	}
+
+	return symbolTable
+}
+
+func NewJBlockSymbolTable(parent *JSymbolTable) *JSymbolTable {
+	symbolTable := NewJSymbolTable(parent)
+	symbolTable.IsBlock = true
+
+	return symbolTable
 }
 
 func (st *JSymbolTable) Get(name any) any {
@@ -34,8 +53,92 @@ func (st *JSymbolTable) Set(name, value any) *JSymbolTable {
 	return st
 }
 
+// SetConst sets value of name which cannot be reassigned
+func (st *JSymbolTable) SetConst(name, value any) *JSymbolTable {
+	if st.Consts == nil {
+		st.Consts = make(map[any]struct{})
+	}
+
+	st.Consts[name] = struct{}{}
+
+	return st.Set(name, value)
+}
+
 func (st *JSymbolTable) Remove(name any) *JSymbolTable {
 	st.Symbols.Del(name)
 
 	return st
 }
+
+// Has reports whether name is defined in the symbol table itself, parents are not checked
+func (st *JSymbolTable) Has(name any) bool {
+	_, ok := st.Symbols.Get(name)
+
+	return ok
+}
+
+func (st *JSymbolTable) IsConst(name any) bool {
+	_, ok := st.Consts[name]
+
+	return ok
+}
+
+// Lookup returns the nearest symbol table in which name is defined, nil if it isn't defined
+func (st *JSymbolTable) Lookup(name any) *JSymbolTable {
+	for symbolTable := st; symbolTable != nil; symbolTable = symbolTable.Parent {
+		if symbolTable.Has(name) {
+			return symbolTable
+		}
+	}
+
+	return nil
+}
+
+// Scope returns the nearest symbol table of function or program, which is not block
+func (st *JSymbolTable) Scope() *JSymbolTable {
+	symbolTable := st
+	for symbolTable.IsBlock {
+		symbolTable = symbolTable.Parent
+	}
+
+	return symbolTable
+}
+
+// Global returns the symbol table of program
+func (st *JSymbolTable) Global() *JSymbolTable {
+	symbolTable := st
+	for symbolTable.Parent != nil {
+		symbolTable = symbolTable.Parent
+	}
+
+	return symbolTable
+}
+
+// DeclareOuter makes plain assignment of name in the scope of st write to target
+func (st *JSymbolTable) DeclareOuter(name any, target *JSymbolTable) {
+	scope := st.Scope()
+	if scope.Outers == nil {
+		scope.Outers = make(map[any]*JSymbolTable)
+	}
+
+	scope.Outers[name] = target
+}
+
+// AssignTarget returns the symbol table written by plain assignment of name: the block which defines name,
+// the symbol table declared by global or nonlocal, or else the scope of function or program
+func (st *JSymbolTable) AssignTarget(name any) *JSymbolTable {
+	symbolTable := st
+	for symbolTable.IsBlock {
+		if symbolTable.Has(name) {
+			return symbolTable
+		}
+
+		symbolTable = symbolTable.Parent
+	}
+
+	if target, ok := symbolTable.Outers[name]; ok {
+		return target
+	}
+
+	return symbolTable
+}
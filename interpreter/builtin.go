@@ -2,11 +2,9 @@ package interpreter
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strconv"
 
 	"github.com/pkg/errors"
@@ -17,21 +15,75 @@ import (
 )
 
 var (
-	NULL        = object.NewJNull()
-	TRUE        = object.NewJNumber(1)
-	FALSE       = object.NewJNumber(0)
-	Len         = object.NewJBuiltInFunction("len", []string{"value"}, ExecuteLen)
-	Type        = object.NewJBuiltInFunction("type", []string{"value"}, ExecuteType)
-	Print       = object.NewJBuiltInFunction("print", []string{"value"}, ExecutePrint)
-	Println     = object.NewJBuiltInFunction("println", []string{"value"}, ExecutePrintln)
-	Input       = object.NewJBuiltInFunction("input", []string{}, ExecuteInput)
-	InputNumber = object.NewJBuiltInFunction("input_number", []string{}, ExecuteInputNumber)
-	IsNumber    = object.NewJBuiltInFunction("is_number", []string{"value"}, ExecuteIsNumber)
-	IsString    = object.NewJBuiltInFunction("is_string", []string{"value"}, ExecuteIsString)
-	IsList      = object.NewJBuiltInFunction("is_list", []string{"value"}, ExecuteIsList)
-	IsFunction  = object.NewJBuiltInFunction("is_function", []string{"value"}, ExecuteIsFunction)
-	RunShell    = object.NewJBuiltInFunction("run_shell", []string{"text"}, ExecuteRunShell)
-	RunScript   = object.NewJBuiltInFunction("run", []string{"filename"}, ExecuteRun)
+	NULL  = object.NewJNull()
+	TRUE  = object.NewJNumber(1)
+	FALSE = object.NewJNumber(0)
+
+	Len = object.NewJBuiltInFunction("len", []string{"value"}, ExecuteLen).
+		SetDoc("Returns the number of characters of string or elements of bytes, list, tuple or map")
+
+	Type = object.NewJBuiltInFunction("type", []string{"value"}, ExecuteType).
+		SetDoc("Returns the type name of value, members of enum have the enum name as their type")
+
+	Print = object.NewJBuiltInFunction("print", []string{"value"}, ExecutePrint).
+		SetDoc("Prints value without new line")
+
+	Println = object.NewJBuiltInFunction("println", []string{"value"}, ExecutePrintln).
+		SetDoc("Prints value followed by new line")
+
+	Input = object.NewJBuiltInFunction("input", []string{}, ExecuteInput).
+		SetDoc("Reads a line from standard input")
+
+	InputNumber = object.NewJBuiltInFunction("input_number", []string{}, ExecuteInputNumber).
+			SetDoc("Reads a line from standard input as number, raises ValueError if it isn't a number")
+
+	IsNumber = object.NewJBuiltInFunction("is_number", []string{"value"}, ExecuteIsNumber).
+			SetDoc("Checks whether value is number")
+
+	IsString = object.NewJBuiltInFunction("is_string", []string{"value"}, ExecuteIsString).
+			SetDoc("Checks whether value is string")
+
+	IsList = object.NewJBuiltInFunction("is_list", []string{"value"}, ExecuteIsList).
+		SetDoc("Checks whether value is list")
+
+	IsTuple = object.NewJBuiltInFunction("is_tuple", []string{"value"}, ExecuteIsTuple).
+		SetDoc("Checks whether value is tuple")
+
+	IsFunction = object.NewJBuiltInFunction("is_function", []string{"value"}, ExecuteIsFunction).
+			SetDoc("Checks whether value is function")
+
+	IsMap = object.NewJBuiltInFunction("is_map", []string{"value"}, ExecuteIsMap).
+		SetDoc("Checks whether value is map")
+
+	Keys = object.NewJBuiltInFunction("keys", []string{"map"}, ExecuteKeys).
+		SetDoc("Returns keys of map as list")
+
+	Values = object.NewJBuiltInFunction("values", []string{"map"}, ExecuteValues).
+		SetDoc("Returns values of map as list")
+
+	Items = object.NewJBuiltInFunction("items", []string{"map"}, ExecuteItems).
+		SetDoc("Returns entries of map as list of (key, value) tuples")
+
+	Has = object.NewJBuiltInFunction("has", []string{"map", "key"}, ExecuteHas).
+		SetDoc("Checks whether map has key")
+
+	Delete = object.NewJBuiltInFunction("delete", []string{"map", "key"}, ExecuteDelete).
+		SetDoc("Deletes key from map and returns the deleted value, or null if key is not present")
+
+	Merge = object.NewJBuiltInFunction("merge", []string{"map", "other"}, ExecuteMerge).
+		SetDoc("Returns a new map with entries of both maps, values of other take precedence")
+
+	Get = object.NewJBuiltInFunction("get", []string{"map", "key", "default"}, ExecuteGet).
+		SetDoc("Returns value of key in map, or default if key is not present")
+
+	Copy = object.NewJBuiltInFunction("copy", []string{"value"}, ExecuteCopy).
+		SetDoc("Returns a new list or map holding the same elements as value")
+
+	DeepCopy = object.NewJBuiltInFunction("deepcopy", []string{"value"}, ExecuteDeepCopy).
+			SetDoc("Returns a copy of value which shares no list or map with it")
+
+	RunScript = object.NewJBuiltInFunction("run", []string{"filename"}, ExecuteRun).
+			SetDoc("Runs script file filename")
 )
 
 func ExecuteLen(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
@@ -41,6 +93,14 @@ func ExecuteLen(function *object.JBuiltInFunction, args []object.JValue) (object
 		return object.NewJNumber(len(argValue.ElementValues)), nil
 	case *object.JString:
 		return object.NewJNumber(len(argValue.Value.(string))), nil
+	case *object.JTuple:
+		return object.NewJNumber(len(argValue.ElementValues)), nil
+	case *object.JMap:
+		return object.NewJNumber(argValue.ElementMap.Size()), nil
+	case *object.JBytes:
+		return object.NewJNumber(len(argValue.Value.([]byte))), nil
+	case *object.JRange:
+		return object.NewJNumber(argValue.Len()), nil
 	}
 
 	return nil, errors.Wrap(&common.JRunTimeError{
@@ -49,14 +109,23 @@ func ExecuteLen(function *object.JBuiltInFunction, args []object.JValue) (object
 			EndPos:   function.EndPos,
 		},
 		Context: function.GetContext(),
-		Details: "First argument must be list or string",
+		Kind:    common.TypeError,
+		Details: "First argument must be list, tuple, map, string, bytes or range",
 	}, "failed to call len")
 }
 
+// ExecuteType returns type name of value, members of enum have the enum name as their type
 func ExecuteType(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
-	arg := args[0]
+	switch arg := args[0].(type) {
+	case *object.JEnumMember:
+		return object.NewJString(arg.Enum.Value), nil
+	case *object.JNumber:
+		if object.IsNull(arg) {
+			return object.NewJString(object.Null), nil
+		}
+	}
 
-	return object.NewJString(object.GetJValueType(arg)), nil
+	return object.NewJString(object.GetJValueType(args[0])), nil
 }
 
 func ExecuteIsNumber(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
@@ -83,6 +152,22 @@ func ExecuteIsList(function *object.JBuiltInFunction, args []object.JValue) (obj
 	return TRUE, nil
 }
 
+func ExecuteIsTuple(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if _, ok := args[0].(*object.JTuple); !ok {
+		return FALSE, nil
+	}
+
+	return TRUE, nil
+}
+
+func ExecuteIsMap(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if _, ok := args[0].(*object.JMap); !ok {
+		return FALSE, nil
+	}
+
+	return TRUE, nil
+}
+
 func ExecuteIsFunction(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
 	if _, ok := args[0].(*object.JFunction); !ok {
 		return FALSE, nil
@@ -128,6 +213,7 @@ func ExecuteInputNumber(function *object.JBuiltInFunction, args []object.JValue)
 					EndPos:   function.EndPos,
 				},
 				Context: function.GetContext(),
+				Kind:    common.ValueError,
 				Details: text + " must be an number",
 			}, "failed to call input_number")
 		}
@@ -145,6 +231,7 @@ func ExecuteRun(function *object.JBuiltInFunction, args []object.JValue) (object
 				EndPos:   function.EndPos,
 			},
 			Context: function.GetContext(),
+			Kind:    common.TypeError,
 			Details: "First arguments must be a string",
 		}, "failed to call run")
 	}
@@ -157,6 +244,7 @@ func ExecuteRun(function *object.JBuiltInFunction, args []object.JValue) (object
 				EndPos:   function.EndPos,
 			},
 			Context: function.GetContext(),
+			Kind:    common.IOError,
 			Details: "Failed to load script " + filename + ", error: " + err.Error(),
 		}, "failed to call run")
 	}
@@ -169,6 +257,7 @@ func ExecuteRun(function *object.JBuiltInFunction, args []object.JValue) (object
 				EndPos:   function.EndPos,
 			},
 			Context: function.GetContext(),
+			Kind:    common.IOError,
 			Details: "Failed to load script " + filename + ", error: " + err.Error(),
 		}, "failed to call run")
 	}
@@ -180,6 +269,7 @@ func ExecuteRun(function *object.JBuiltInFunction, args []object.JValue) (object
 				EndPos:   function.EndPos,
 			},
 			Context: function.GetContext(),
+			Kind:    common.RuntimeError,
 			Details: "Failed to finish executing script " + filename + "\n" + err.Error(),
 		}, "failed to call run")
 	}
@@ -187,23 +277,172 @@ func ExecuteRun(function *object.JBuiltInFunction, args []object.JValue) (object
 	return nil, nil
 }
 
-func ExecuteRunShell(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
-	shellStr := args[0].GetValue().(string)
-	cmd := exec.Command("/bin/sh", "-c", shellStr)
+func ExecuteKeys(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	mapValue, err := getMapArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
 
-	var outBuf, errBuf bytes.Buffer
-	cmd.Stdout = &outBuf
-	cmd.Stderr = &errBuf
+	elementValues := make([]object.JValue, 0, mapValue.ElementMap.Size())
+	mapValue.Range(func(key, _ object.JValue) bool {
+		elementValues = append(elementValues, key)
 
-	if err := cmd.Run(); err != nil || errBuf.Len() > 0 {
-		return nil, errors.Wrap(&common.JRunTimeError{
-			JError: &common.JError{
-				StartPos: function.StartPos,
-				EndPos:   function.EndPos,
-			},
-			Context: function.GetContext(),
-		}, errBuf.String())
+		return true
+	})
+
+	return object.NewJList(elementValues).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteValues(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	mapValue, err := getMapArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	elementValues := make([]object.JValue, 0, mapValue.ElementMap.Size())
+	mapValue.Range(func(_, value object.JValue) bool {
+		elementValues = append(elementValues, value)
+
+		return true
+	})
+
+	return object.NewJList(elementValues).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteItems(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	mapValue, err := getMapArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	elementValues := make([]object.JValue, 0, mapValue.ElementMap.Size())
+	mapValue.Range(func(key, value object.JValue) bool {
+		elementValues = append(elementValues, object.NewJTuple([]object.JValue{key, value}))
+
+		return true
+	})
+
+	return object.NewJList(elementValues).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteHas(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	mapValue, err := getMapArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkHashKeyArg(function, args[1]); err != nil {
+		return nil, err
+	}
+
+	if _, ok := mapValue.Get(args[1]); !ok {
+		return FALSE, nil
+	}
+
+	return TRUE, nil
+}
+
+// ExecuteDelete deletes key from map and returns the deleted value, or null if key is not present
+func ExecuteDelete(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	mapValue, err := getMapArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkHashKeyArg(function, args[1]); err != nil {
+		return nil, err
 	}
 
-	return object.NewJString(outBuf.String()), nil
+	value, ok := mapValue.Get(args[1])
+	if !ok {
+		return NULL, nil
+	}
+
+	mapValue.Del(args[1])
+
+	return value, nil
+}
+
+// ExecuteMerge returns a new map with entries of both maps, values of the second map take precedence
+func ExecuteMerge(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	mapValue, err := getMapArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	otherMapValue, ok := args[1].(*object.JMap)
+	if !ok {
+		return nil, createArgError(function, "Second argument must be map")
+	}
+
+	resMap := object.NewJMap(mapValue.ElementMap.Copy())
+	otherMapValue.Range(func(key, value object.JValue) bool {
+		resMap.Set(key, value)
+
+		return true
+	})
+
+	return resMap.SetJPos(function.StartPos, function.EndPos).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteGet(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	mapValue, err := getMapArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkHashKeyArg(function, args[1]); err != nil {
+		return nil, err
+	}
+
+	if value, ok := mapValue.Get(args[1]); ok {
+		return value, nil
+	}
+
+	return args[2], nil
+}
+
+// ExecuteCopy returns a new list or map holding the same elements as the argument
+func ExecuteCopy(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	return object.ShallowCopy(args[0]), nil
+}
+
+// ExecuteDeepCopy returns a copy of the argument which shares no list or map with it
+func ExecuteDeepCopy(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	return object.DeepCopy(args[0]), nil
+}
+
+func getMapArg(function *object.JBuiltInFunction, arg object.JValue) (*object.JMap, error) {
+	mapValue, ok := arg.(*object.JMap)
+	if !ok {
+		return nil, createArgError(function, "First argument must be map")
+	}
+
+	return mapValue, nil
+}
+
+func checkHashKeyArg(function *object.JBuiltInFunction, arg object.JValue) error {
+	if !object.CanHashed(arg) {
+		return createArgError(function, "Cannot hashed")
+	}
+
+	return nil
+}
+
+// createArgError creates type error at the call of built-in function
+func createArgError(function *object.JBuiltInFunction, details string) error {
+	return createBuiltInError(function, common.TypeError, details)
+}
+
+// createBuiltInError creates runtime error of kind at the call of built-in function
+func createBuiltInError(function *object.JBuiltInFunction, kind, details string) error {
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: function.StartPos,
+			EndPos:   function.EndPos,
+		},
+		Context: function.GetContext(),
+		Kind:    kind,
+		Details: details,
+	}, "failed to call "+function.Value.(string))
 }
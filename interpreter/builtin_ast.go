@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/parser"
+	"github.com/IfanTsai/jirachi/token"
+)
+
+var (
+	AstVar = object.NewJBuiltInFunction("ast_var", []string{"name"}, ExecuteAstVar).
+		SetDoc("Creates ast accessing variable name, which isn't renamed by quote")
+
+	AstAssign = object.NewJBuiltInFunction("ast_assign", []string{"name", "value"}, ExecuteAstAssign).
+			SetDoc("Creates ast assigning value to variable name, which isn't renamed by quote")
+
+	AstCall = object.NewJBuiltInFunction("ast_call", []string{"callee", "args"}, ExecuteAstCall).
+		SetDoc("Creates ast calling callee with args, callee is name of variable or ast")
+
+	AstBlock = object.NewJBuiltInFunction("ast_block", []string{"statements"}, ExecuteAstBlock).
+			SetDoc("Creates ast running statements in order, its value is the value of the last statement")
+)
+
+// ExecuteAstVar creates ast accessing variable name, the name isn't renamed by quote
+// so that macros can refer to variables of the caller
+func ExecuteAstVar(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	varAccessNode, ok := newVarAccessNode(function, args[0])
+	if !ok {
+		return nil, createArgError(function, "First argument must be string or ast of variable")
+	}
+
+	return newAstValue(function, varAccessNode), nil
+}
+
+// ExecuteAstAssign creates ast assigning value to variable name, value is converted to ast like unquote
+func ExecuteAstAssign(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	varAccessNode, ok := newVarAccessNode(function, args[0])
+	if !ok {
+		return nil, createArgError(function, "First argument must be string or ast of variable")
+	}
+
+	valueNode, ok := valueToNode(args[1], function.StartPos, function.EndPos)
+	if !ok {
+		return nil, createArgError(function, "Second argument must be ast, number, string, list or null")
+	}
+
+	return newAstValue(function, &parser.JVarAssignNode{
+		JBaseNode: &parser.JBaseNode{
+			Token:    varAccessNode.Token,
+			StartPos: function.StartPos,
+			EndPos:   function.EndPos,
+		},
+		Node: valueNode,
+	}), nil
+}
+
+// ExecuteAstCall creates ast calling callee with args, callee is name of variable or ast
+func ExecuteAstCall(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	var calleeNode parser.JNode
+	if callee, ok := args[0].(*object.JAst); ok {
+		calleeNode = callee.Node
+	} else if calleeNode, ok = newVarAccessNode(function, args[0]); !ok {
+		return nil, createArgError(function, "First argument must be string or ast")
+	}
+
+	argNodes, ok := listToNodes(function, args[1])
+	if !ok {
+		return nil, createArgError(function, "Second argument must be list of ast, number, string, list or null")
+	}
+
+	return newAstValue(function, &parser.JCallExprNode{
+		JBaseNode: &parser.JBaseNode{
+			StartPos: function.StartPos,
+			EndPos:   function.EndPos,
+		},
+		CallNode: calleeNode,
+		ArgNodes: argNodes,
+	}), nil
+}
+
+// ExecuteAstBlock creates ast running statements in order, whose value is the value of the last statement
+func ExecuteAstBlock(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	statementNodes, ok := listToNodes(function, args[0])
+	if !ok || len(statementNodes) == 0 {
+		return nil, createArgError(function, "First argument must be non-empty list of ast, number, string, list or null")
+	}
+
+	return newAstValue(function, &parser.JListNode{
+		JBaseNode: &parser.JBaseNode{
+			StartPos: function.StartPos,
+			EndPos:   function.EndPos,
+		},
+		ElementNodes:      statementNodes,
+		IsBlockStatements: true,
+	}), nil
+}
+
+func newAstValue(function *object.JBuiltInFunction, node parser.JNode) object.JValue {
+	return object.NewJAst(node).SetJPos(function.StartPos, function.EndPos).SetJContext(function.GetContext())
+}
+
+// newVarAccessNode converts name or ast of variable to node accessing the variable
+func newVarAccessNode(function *object.JBuiltInFunction, arg object.JValue) (*parser.JVarAccessNode, bool) {
+	if ast, ok := arg.(*object.JAst); ok {
+		varAccessNode, ok := ast.Node.(*parser.JVarAccessNode)
+
+		return varAccessNode, ok
+	}
+
+	name, ok := arg.GetValue().(string)
+	if !ok || name == "" {
+		return nil, false
+	}
+
+	return &parser.JVarAccessNode{
+		JBaseNode: &parser.JBaseNode{
+			Token:    token.NewJToken(token.IDENTIFIER, name, function.StartPos, function.EndPos),
+			StartPos: function.StartPos,
+			EndPos:   function.EndPos,
+		},
+	}, true
+}
+
+// listToNodes converts elements of list to nodes like unquote
+func listToNodes(function *object.JBuiltInFunction, arg object.JValue) ([]parser.JNode, bool) {
+	list, ok := arg.(*object.JList)
+	if !ok {
+		return nil, false
+	}
+
+	nodes := make([]parser.JNode, len(list.ElementValues))
+	for index, elementValue := range list.ElementValues {
+		if nodes[index], ok = valueToNode(elementValue, function.StartPos, function.EndPos); !ok {
+			return nil, false
+		}
+	}
+
+	return nodes, true
+}
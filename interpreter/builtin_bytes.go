@@ -0,0 +1,240 @@
+package interpreter
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+)
+
+var (
+	IsBytes = object.NewJBuiltInFunction("is_bytes", []string{"value"}, ExecuteIsBytes).
+		SetDoc("Checks whether value is bytes")
+
+	Encode = object.NewJBuiltInFunction("encode", []string{"text", "encoding"}, ExecuteEncode).
+		SetDoc("Converts text to bytes with encoding utf-8, ascii or latin-1")
+
+	Decode = object.NewJBuiltInFunction("decode", []string{"data", "encoding"}, ExecuteDecode).
+		SetDoc("Converts data to string with encoding utf-8, ascii or latin-1")
+
+	ToHex = object.NewJBuiltInFunction("to_hex", []string{"data"}, ExecuteToHex).
+		SetDoc("Converts data to hex string")
+
+	FromHex = object.NewJBuiltInFunction("from_hex", []string{"text"}, ExecuteFromHex).
+		SetDoc("Converts hex string to bytes")
+
+	ToBase64 = object.NewJBuiltInFunction("to_base64", []string{"data"}, ExecuteToBase64).
+			SetDoc("Converts data to base64 string")
+
+	FromBase64 = object.NewJBuiltInFunction("from_base64", []string{"text"}, ExecuteFromBase64).
+			SetDoc("Converts base64 string to bytes")
+
+	ReadBytes = object.NewJBuiltInFunction("read_bytes", []string{"filename"}, ExecuteReadBytes).
+			SetDoc("Reads the whole file filename as bytes")
+
+	WriteBytes = object.NewJBuiltInFunction("write_bytes", []string{"filename", "data"}, ExecuteWriteBytes).
+			SetDoc("Writes data to file filename, the file is truncated if it exists")
+)
+
+// supported encodings of encode and decode
+const (
+	encodingUTF8   = "utf-8"
+	encodingASCII  = "ascii"
+	encodingLatin1 = "latin-1"
+)
+
+func ExecuteIsBytes(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if _, ok := args[0].(*object.JBytes); !ok {
+		return FALSE, nil
+	}
+
+	return TRUE, nil
+}
+
+// ExecuteEncode converts string to bytes with encoding utf-8, ascii or latin-1
+func ExecuteEncode(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	text, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	encoding, err := getEncodingArg(function, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+
+	switch encoding {
+	case encodingUTF8:
+		data = []byte(text)
+	case encodingASCII, encodingLatin1:
+		maxRune := rune(utf8.RuneSelf - 1)
+		if encoding == encodingLatin1 {
+			maxRune = 0xff
+		}
+
+		data = make([]byte, 0, len(text))
+		for _, char := range text {
+			if char > maxRune {
+				return nil, createBuiltInError(function, common.ValueError, "Cannot encode '"+string(char)+"' with "+encoding)
+			}
+
+			data = append(data, byte(char))
+		}
+	}
+
+	return object.NewJBytes(data).SetJContext(function.GetContext()), nil
+}
+
+// ExecuteDecode converts bytes to string with encoding utf-8, ascii or latin-1
+func ExecuteDecode(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	data, err := getBytesArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	encoding, err := getEncodingArg(function, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+
+	switch encoding {
+	case encodingUTF8:
+		if !utf8.Valid(data) {
+			return nil, createBuiltInError(function, common.ValueError, "Cannot decode invalid "+encoding+" bytes")
+		}
+
+		text = string(data)
+	case encodingASCII:
+		for _, char := range data {
+			if char >= utf8.RuneSelf {
+				return nil, createBuiltInError(function, common.ValueError, "Cannot decode invalid "+encoding+" bytes")
+			}
+		}
+
+		text = string(data)
+	case encodingLatin1:
+		strBuilder := strings.Builder{}
+		for _, char := range data {
+			strBuilder.WriteRune(rune(char))
+		}
+
+		text = strBuilder.String()
+	}
+
+	return object.NewJString(text).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteToHex(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	data, err := getBytesArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewJString(hex.EncodeToString(data)).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteFromHex(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	text, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	data, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, createBuiltInError(function, common.ValueError, "Invalid hex string, error: "+err.Error())
+	}
+
+	return object.NewJBytes(data).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteToBase64(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	data, err := getBytesArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewJString(base64.StdEncoding.EncodeToString(data)).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteFromBase64(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	text, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, createBuiltInError(function, common.ValueError, "Invalid base64 string, error: "+err.Error())
+	}
+
+	return object.NewJBytes(data).SetJContext(function.GetContext()), nil
+}
+
+// ExecuteReadBytes reads the whole binary file
+func ExecuteReadBytes(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	filename, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, createBuiltInError(function, common.IOError, "Failed to read "+filename+", error: "+err.Error())
+	}
+
+	return object.NewJBytes(data).SetJContext(function.GetContext()), nil
+}
+
+// ExecuteWriteBytes writes bytes to file, the file is truncated if it exists
+func ExecuteWriteBytes(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	filename, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	data, ok := args[1].GetValue().([]byte)
+	if !ok {
+		return nil, createArgError(function, "Second argument must be bytes")
+	}
+
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		return nil, createBuiltInError(function, common.IOError, "Failed to write "+filename+", error: "+err.Error())
+	}
+
+	return object.NewJNumber(len(data)).SetJContext(function.GetContext()), nil
+}
+
+func getBytesArg(function *object.JBuiltInFunction, arg object.JValue) ([]byte, error) {
+	data, ok := arg.GetValue().([]byte)
+	if !ok {
+		return nil, createArgError(function, "First argument must be bytes")
+	}
+
+	return data, nil
+}
+
+func getEncodingArg(function *object.JBuiltInFunction, arg object.JValue) (string, error) {
+	encoding, ok := arg.GetValue().(string)
+	if !ok {
+		return "", createArgError(function, "Second argument must be string")
+	}
+
+	switch strings.ToLower(strings.ReplaceAll(encoding, "_", "-")) {
+	case encodingUTF8, "utf8":
+		return encodingUTF8, nil
+	case encodingASCII:
+		return encodingASCII, nil
+	case encodingLatin1, "latin1", "iso-8859-1":
+		return encodingLatin1, nil
+	}
+
+	return "", createBuiltInError(function, common.ValueError, "Unknown encoding '"+encoding+"', expected utf-8, ascii or latin-1")
+}
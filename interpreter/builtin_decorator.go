@@ -0,0 +1,144 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IfanTsai/jirachi/interpreter/object"
+)
+
+var (
+	Memoize = object.NewJBuiltInFunction("memoize", []string{"function"}, ExecuteMemoize).
+		SetDoc("Decorator caching results of function by args")
+
+	Trace = object.NewJBuiltInFunction("trace", []string{"function"}, ExecuteTrace).
+		SetDoc("Decorator printing args and result of each call of function")
+
+	Retry = object.NewJBuiltInFunction("retry", []string{"times"}, ExecuteRetry).
+		SetDoc("Decorator calling function up to times times until it doesn't raise error")
+)
+
+// traceDepth is the number of traced calls which haven't returned, used to indent trace output
+var traceDepth = 0
+
+// ExecuteMemoize returns function which caches results of function by args,
+// calls whose args cannot be hashed are not cached
+func ExecuteMemoize(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if !isCallable(args[0]) {
+		return nil, createArgError(function, "First argument must be function")
+	}
+
+	callValue := args[0]
+	cache := make(map[any]object.JValue)
+
+	return wrapFunction(callValue, func(_ *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+		argsTuple := object.NewJTuple(args)
+		if !object.CanHashed(argsTuple) {
+			return callFunction(callValue, args)
+		}
+
+		key := object.HashKey(argsTuple)
+		if resValue, ok := cache[key]; ok {
+			return resValue, nil
+		}
+
+		resValue, err := callFunction(callValue, args)
+		if err != nil {
+			return nil, err
+		}
+
+		cache[key] = resValue
+
+		return resValue, nil
+	}), nil
+}
+
+// ExecuteTrace returns function which prints its args before calling function and the result after it returns
+func ExecuteTrace(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if !isCallable(args[0]) {
+		return nil, createArgError(function, "First argument must be function")
+	}
+
+	callValue := args[0]
+
+	return wrapFunction(callValue, func(_ *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+		argStrings := make([]string, len(args))
+		for index, arg := range args {
+			argStrings[index] = arg.String()
+		}
+
+		indent := strings.Repeat("  ", traceDepth)
+		fmt.Printf("%s-> %v(%s)\n", indent, callValue.GetValue(), strings.Join(argStrings, ", "))
+
+		traceDepth++
+		resValue, err := callFunction(callValue, args)
+		traceDepth--
+
+		if err != nil {
+			fmt.Printf("%s<- %v failed\n", indent, callValue.GetValue())
+
+			return nil, err
+		}
+
+		fmt.Printf("%s<- %v = %v\n", indent, callValue.GetValue(), resValue)
+
+		return resValue, nil
+	}), nil
+}
+
+// ExecuteRetry returns decorator which makes function be called up to times times until it doesn't raise error,
+// the error of the last call is raised
+func ExecuteRetry(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	times, ok := args[0].GetValue().(int)
+	if !ok || times < 1 {
+		return nil, createArgError(function, "First argument must be positive integer")
+	}
+
+	decorator := object.NewJBuiltInFunction("retry", []string{"function"},
+		func(decorator *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+			if !isCallable(args[0]) {
+				return nil, createArgError(decorator, "First argument must be function")
+			}
+
+			callValue := args[0]
+
+			return wrapFunction(callValue, func(_ *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+				var err error
+				for count := 0; count < times; count++ {
+					var resValue object.JValue
+					if resValue, err = callFunction(callValue, args); err == nil {
+						return resValue, nil
+					}
+				}
+
+				return nil, err
+			}), nil
+		})
+
+	return decorator.SetJPos(function.StartPos, function.EndPos).SetJContext(function.GetContext()), nil
+}
+
+func isCallable(value object.JValue) bool {
+	switch value.(type) {
+	case *object.JFunction, *object.JBuiltInFunction:
+		return true
+	}
+
+	return false
+}
+
+// wrapFunction returns built-in function with the same name, args and doc as callValue, which calls executeFunc
+func wrapFunction(callValue object.JValue, executeFunc object.ExecuteFunc) object.JValue {
+	var wrapper *object.JBuiltInFunction
+
+	switch function := callValue.(type) {
+	case *object.JBuiltInFunction:
+		wrapper = object.NewJBuiltInFunction(function.Value, function.ArgNames, executeFunc).
+			SetOptionalArgCount(function.OptionalArgCount).
+			SetDoc(function.Doc)
+	case *object.JFunction:
+		wrapper = object.NewJBuiltInFunction(function.Value, function.ArgNames, executeFunc).SetDoc(function.Doc)
+	}
+
+	return wrapper.SetJPos(callValue.GetStartPos(), callValue.GetEndPos()).SetJContext(callValue.GetContext())
+}
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+)
+
+var (
+	Error = object.NewJBuiltInFunction("error", []string{"message", "data"}, ExecuteError).SetOptionalArgCount(1).
+		SetDoc("Creates error value with message, fields of map data can be accessed as fields of the error")
+
+	IsError = object.NewJBuiltInFunction("is_error", []string{"value"}, ExecuteIsError).
+		SetDoc("Checks whether value is error")
+
+	Catch = object.NewJBuiltInFunction("catch", []string{"function"}, ExecuteCatch).
+		SetDoc("Calls function without args and returns the raised runtime error as error value")
+)
+
+// kind of error created by error(), unless data has a string "kind"
+const userErrorKind = "Error"
+
+// ExecuteError creates error value with message and optional map data, whose fields can be accessed
+// as fields of the error, eg. error("failed", {"code": 2}).code
+func ExecuteError(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	message, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	var data object.JValue = object.NewJNull()
+	kind := userErrorKind
+
+	if len(args) > 1 && !object.IsNull(args[1]) {
+		dataMap, ok := args[1].(*object.JMap)
+		if !ok {
+			return nil, createArgError(function, "Second argument must be map")
+		}
+
+		if kindValue, ok := dataMap.Get(object.NewJString("kind")); ok {
+			if kind, ok = kindValue.GetValue().(string); !ok {
+				return nil, createBuiltInError(function, common.ValueError, "Kind of error must be string")
+			}
+		}
+
+		data = dataMap
+	}
+
+	line := 0
+	if function.StartPos != nil {
+		line = int(function.StartPos.Ln + 1)
+	}
+
+	traceback := common.GenerateTraceBack(function.StartPos, function.GetContext())
+
+	return object.NewJErrorValue(kind, message, data, line, traceback).
+		SetJPos(function.StartPos, function.EndPos).
+		SetJContext(function.GetContext()), nil
+}
+
+func ExecuteIsError(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if _, ok := args[0].(*object.JErrorValue); !ok {
+		return FALSE, nil
+	}
+
+	return TRUE, nil
+}
+
+// ExecuteCatch calls function without arguments, the runtime error raised by it is returned as error value
+func ExecuteCatch(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	switch args[0].(type) {
+	case *object.JFunction, *object.JBuiltInFunction:
+	default:
+		return nil, createArgError(function, "First argument must be function")
+	}
+
+	resValue, err := callFunction(args[0], nil)
+	if err != nil {
+		return object.MakeJErrorValue(err).SetJContext(function.GetContext()), nil
+	}
+
+	if resValue == nil {
+		return object.NewJNull().SetJContext(function.GetContext()), nil
+	}
+
+	return resValue, nil
+}
@@ -0,0 +1,143 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/lexer"
+	"github.com/IfanTsai/jirachi/parser"
+)
+
+var (
+	Eval = object.NewJBuiltInFunction("eval", []string{"source", "scope"}, ExecuteEval).SetOptionalArgCount(1).
+		SetDoc("Evaluates expression source with variables of map scope and returns its value")
+
+	Exec = object.NewJBuiltInFunction("exec", []string{"source", "scope"}, ExecuteExec).SetOptionalArgCount(1).
+		SetDoc("Runs statements source, variables assigned at top level are written back into map scope")
+)
+
+// builtInSymbols are built-in values of GlobalSymbolTable before any script runs,
+// they are the only variables visible to eval and exec besides the scope
+var builtInSymbols = make(map[any]any)
+
+// ExecuteEval evaluates source as a single expression and returns its value, variables of the expression are
+// looked up in map scope and built-in values, errors point to positions inside source
+func ExecuteEval(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	resValue, _, err := runSource(function, "<eval>", args, (*parser.JParser).ParseExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	if resValue == nil {
+		return object.NewJNull().SetJContext(function.GetContext()), nil
+	}
+
+	return resValue, nil
+}
+
+// ExecuteExec runs source as statements like eval, variables assigned at top level of source are written back
+// into map scope
+func ExecuteExec(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	_, symbolTable, err := runSource(function, "<exec>", args, (*parser.JParser).Parse)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope, ok := args[len(args)-1].(*object.JMap); ok && len(args) > 1 {
+		symbolTable.Symbols.Range(func(name any, value any) bool {
+			if builtInValue, ok := builtInSymbols[name]; !ok || builtInValue != value {
+				scope.Set(object.NewJString(name), value.(object.JValue))
+			}
+
+			return true
+		})
+	}
+
+	return object.NewJNull().SetJContext(function.GetContext()), nil
+}
+
+// runSource parses source of args with parse and runs it in a new symbol table created from scope of args
+func runSource(
+	function *object.JBuiltInFunction,
+	filename string,
+	args []object.JValue,
+	parse func(*parser.JParser) (parser.JNode, error),
+) (object.JValue, *common.JSymbolTable, error) {
+	source, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, nil, createArgError(function, "First argument must be string")
+	}
+
+	symbolTable, err := newScopeSymbolTable(function, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := lexer.NewJLexer(filename, source).MakeTokens()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to make tokens of "+filename)
+	}
+
+	ast, err := parse(parser.NewJParser(tokens, -1))
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to parse tokens of "+filename)
+	}
+
+	context := common.NewJContext(filename, symbolTable, function.GetContext(), function.StartPos)
+
+	if ast, err = expandMacros(ast, context); err != nil {
+		return nil, nil, err
+	}
+
+	resValue, err := NewJInterpreter(context).Interpreter(ast)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resValue, symbolTable, nil
+}
+
+// newScopeSymbolTable creates symbol table holding built-in values and entries of the optional map scope,
+// whose keys must be strings
+func newScopeSymbolTable(function *object.JBuiltInFunction, args []object.JValue) (*common.JSymbolTable, error) {
+	symbolTable := newBuiltInSymbolTable()
+
+	if len(args) < 2 || object.IsNull(args[1]) {
+		return symbolTable, nil
+	}
+
+	scope, ok := args[1].(*object.JMap)
+	if !ok {
+		return nil, createArgError(function, "Second argument must be map")
+	}
+
+	var err error
+
+	scope.Range(func(key, value object.JValue) bool {
+		name, ok := key.GetValue().(string)
+		if !ok {
+			err = createArgError(function, fmt.Sprintf("Name of variable in scope must be string, got '%v'", key))
+
+			return false
+		}
+
+		symbolTable.Set(name, value)
+
+		return true
+	})
+
+	return symbolTable, err
+}
+
+// newBuiltInSymbolTable creates symbol table which only holds built-in values
+func newBuiltInSymbolTable() *common.JSymbolTable {
+	symbolTable := common.NewJSymbolTable(nil)
+	for name, value := range builtInSymbols {
+		symbolTable.Set(name, value)
+	}
+
+	return symbolTable
+}
@@ -0,0 +1,213 @@
+package interpreter
+
+import (
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+)
+
+var (
+	Range = object.NewJBuiltInFunction("range", []string{"start", "end", "step"}, ExecuteRange).SetOptionalArgCount(2).
+		SetDoc("Creates range from start to end by step, range(end) starts from 0")
+
+	Iter = object.NewJBuiltInFunction("iter", []string{"value"}, ExecuteIter).
+		SetDoc("Returns iterator over value")
+
+	Next = object.NewJBuiltInFunction("next", []string{"iterator", "default"}, ExecuteNext).SetOptionalArgCount(1).
+		SetDoc("Returns the next value of iterator, or default if the iterator is exhausted")
+
+	ToList = object.NewJBuiltInFunction("to_list", []string{"value"}, ExecuteToList).
+		SetDoc("Collects all values of value into a new list")
+
+	Map = object.NewJBuiltInFunction("map", []string{"value", "function"}, ExecuteMap).
+		SetDoc("Returns lazy iterator yielding function(v) for every v of value")
+
+	Filter = object.NewJBuiltInFunction("filter", []string{"value", "function"}, ExecuteFilter).
+		SetDoc("Returns lazy iterator yielding every v of value for which function(v) is true")
+
+	Sum = object.NewJBuiltInFunction("sum", []string{"value"}, ExecuteSum).
+		SetDoc("Returns the sum of numbers of value")
+)
+
+// ExecuteRange creates range, range(end) starts from 0 and range(start, end) steps by 1
+func ExecuteRange(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	var (
+		resValue object.JValue
+		err      error
+	)
+
+	switch len(args) {
+	case 1:
+		resValue, err = object.MakeJRange(object.NewJNumber(0), args[0], nil)
+	case 2:
+		resValue, err = object.MakeJRange(args[0], args[1], nil)
+	default:
+		resValue, err = object.MakeJRange(args[0], args[1], args[2])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resValue.SetJContext(function.GetContext()), nil
+}
+
+func ExecuteIter(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	iterator, err := getIteratorArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return iterator.SetJContext(function.GetContext()), nil
+}
+
+// ExecuteNext returns the next value of iterator, the default value is returned if the iterator
+// is exhausted, it is an error to call next on an exhausted iterator without default value
+func ExecuteNext(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	iterator, ok := args[0].(*object.JIterator)
+	if !ok {
+		return nil, createArgError(function, "First argument must be iterator")
+	}
+
+	value, ok, err := iterator.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return value, nil
+	}
+
+	if len(args) > 1 {
+		return args[1], nil
+	}
+
+	return nil, createBuiltInError(function, common.StopIteration, "Iterator is exhausted")
+}
+
+// ExecuteToList consumes iterable and collects all values into a new list
+func ExecuteToList(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	iterator, err := getIteratorArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var elementValues []object.JValue
+	if err := rangeIterator(iterator, func(value object.JValue) error {
+		elementValues = append(elementValues, value)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return object.NewJList(elementValues).SetJContext(function.GetContext()), nil
+}
+
+// ExecuteMap returns a lazy iterator which yields function(value) for every value of iterable,
+// iterable is the first argument, as the container is for other built-ins such as has and get
+func ExecuteMap(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	iterator, err := getIteratorArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := getCallableArg(function, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewJIterator(func() (object.JValue, bool, error) {
+		value, ok, err := iterator.Next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		resValue, err := callFunction(callback, []object.JValue{value})
+		if err != nil {
+			return nil, false, err
+		}
+
+		return resValue, true, nil
+	}).SetJContext(function.GetContext()), nil
+}
+
+// ExecuteFilter returns a lazy iterator which yields values of iterable satisfying function(value)
+func ExecuteFilter(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	iterator, err := getIteratorArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := getCallableArg(function, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewJIterator(func() (object.JValue, bool, error) {
+		for {
+			value, ok, err := iterator.Next()
+			if err != nil || !ok {
+				return nil, false, err
+			}
+
+			resValue, err := callFunction(callback, []object.JValue{value})
+			if err != nil {
+				return nil, false, err
+			}
+
+			if resValue != nil && resValue.IsTrue() {
+				return value, true, nil
+			}
+		}
+	}).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteSum(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	iterator, err := getIteratorArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var resValue object.JValue = object.NewJNumber(0).SetJContext(function.GetContext())
+	if err := rangeIterator(iterator, func(value object.JValue) error {
+		resValue, err = resValue.AddTo(value)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return resValue, nil
+}
+
+// rangeIterator calls f for every value of iterator until the iterator is exhausted or f returns error
+func rangeIterator(iterator *object.JIterator, f func(value object.JValue) error) error {
+	for {
+		value, ok, err := iterator.Next()
+		if err != nil || !ok {
+			return err
+		}
+
+		if err := f(value); err != nil {
+			return err
+		}
+	}
+}
+
+func getIteratorArg(function *object.JBuiltInFunction, arg object.JValue) (*object.JIterator, error) {
+	iterator, ok := object.Iter(arg)
+	if !ok {
+		return nil, createArgError(function, "First argument must be iterable")
+	}
+
+	return iterator, nil
+}
+
+func getCallableArg(function *object.JBuiltInFunction, arg object.JValue) (object.JValue, error) {
+	switch arg.(type) {
+	case *object.JFunction, *object.JBuiltInFunction:
+		return arg, nil
+	}
+
+	return nil, createArgError(function, "Second argument must be function")
+}
@@ -0,0 +1,337 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/pkg/orderedmap"
+)
+
+var (
+	RunShell = object.NewJBuiltInFunction("run_shell", []string{"text", "options"}, ExecuteRunShell).
+			SetOptionalArgCount(1).
+			SetDoc("Runs text with /bin/sh and returns map of stdout, stderr, code and duration, " +
+			"options map can have stdin, env, cwd, timeout and check")
+
+	RunCmd = object.NewJBuiltInFunction("run_cmd", []string{"args", "options"}, ExecuteRunCmd).
+		SetOptionalArgCount(1).
+		SetDoc("Runs program args[0] with the rest of args without shell and returns map of stdout, stderr, " +
+			"code and duration, options are the same as run_shell")
+
+	Shell = object.NewJBuiltInFunction("@", []string{"text"}, ExecuteShell).
+		SetDoc("Runs text with /bin/sh and returns its standard output, raises IOError if the command exits " +
+			"with non-zero code")
+)
+
+// processOptions are options of run_shell and run_cmd
+type processOptions struct {
+	stdin   string
+	env     []string // added to the environment of the interpreter
+	cwd     string
+	timeout time.Duration // 0 means no timeout
+	check   bool          // raise IOError if the command exits with non-zero code
+}
+
+type processResult struct {
+	stdout   string
+	stderr   string
+	code     int
+	duration time.Duration
+}
+
+// ExecuteRunShell runs text with /bin/sh, the command doesn't fail by exit code or output on stderr
+// unless check option is true
+func ExecuteRunShell(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	text, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	options, err := getProcessOptions(function, args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := runProcess(function, []string{"/bin/sh", "-c", text}, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.toMap(function), nil
+}
+
+// ExecuteRunCmd runs program with args directly, so that args never need quoting
+func ExecuteRunCmd(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	cmdArgs, ok := shellWords(args[0])
+	if _, isList := args[0].(*object.JList); !ok || !isList || len(cmdArgs) == 0 {
+		return nil, createArgError(function, "First argument must be non-empty list of strings or numbers")
+	}
+
+	options, err := getProcessOptions(function, args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := runProcess(function, cmdArgs, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.toMap(function), nil
+}
+
+// ExecuteShell runs text of @"..." with /bin/sh and returns only its standard output
+func ExecuteShell(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	text, ok := args[0].GetValue().(string)
+	if !ok {
+		return nil, createArgError(function, "First argument must be string")
+	}
+
+	result, err := runProcess(function, []string{"/bin/sh", "-c", text}, &processOptions{check: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewJString(result.stdout).SetJContext(function.GetContext()), nil
+}
+
+// getProcessOptions converts optional map of options to processOptions
+func getProcessOptions(function *object.JBuiltInFunction, args []object.JValue) (*processOptions, error) {
+	options := &processOptions{}
+	if len(args) == 0 {
+		return options, nil
+	}
+
+	optionMap, ok := args[0].(*object.JMap)
+	if !ok {
+		return nil, createArgError(function, "Second argument must be map of options")
+	}
+
+	var err error
+
+	optionMap.Range(func(key, value object.JValue) bool {
+		switch key.GetValue() {
+		case "stdin":
+			if options.stdin, ok = value.GetValue().(string); !ok {
+				err = createArgError(function, "Option stdin must be string")
+			}
+		case "env":
+			options.env, err = getEnvOption(function, value)
+		case "cwd":
+			if options.cwd, ok = value.GetValue().(string); !ok {
+				err = createArgError(function, "Option cwd must be string")
+			}
+		case "timeout":
+			var seconds float64
+			switch number := value.GetValue().(type) {
+			case int:
+				seconds = float64(number)
+			case float64:
+				seconds = number
+			}
+
+			if _, isNumber := value.(*object.JNumber); !isNumber || seconds <= 0 {
+				err = createArgError(function, "Option timeout must be positive number of seconds")
+			}
+
+			options.timeout = time.Duration(seconds * float64(time.Second))
+		case "check":
+			options.check = value.IsTrue()
+		default:
+			err = createBuiltInError(function, common.ValueError,
+				fmt.Sprintf("Unknown option %v, expected stdin, env, cwd, timeout or check", key))
+		}
+
+		return err == nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// getEnvOption converts map of environment variables to NAME=value strings
+func getEnvOption(function *object.JBuiltInFunction, value object.JValue) ([]string, error) {
+	envMap, ok := value.(*object.JMap)
+	if !ok {
+		return nil, createArgError(function, "Option env must be map")
+	}
+
+	var env []string
+
+	envMap.Range(func(name, value object.JValue) bool {
+		words, isWord := shellWords(value)
+		if _, isList := value.(*object.JList); !isWord || isList {
+			ok = false
+
+			return false
+		}
+
+		env = append(env, fmt.Sprintf("%v=%s", name, words[0]))
+
+		return true
+	})
+
+	if !ok {
+		return nil, createArgError(function, "Values of option env must be strings or numbers")
+	}
+
+	return env, nil
+}
+
+// runProcess runs program cmdArgs[0] with the rest of cmdArgs, errors of starting the program and timeout
+// are always raised, non-zero exit code is raised only if options.check is true
+func runProcess(function *object.JBuiltInFunction, cmdArgs []string, options *processOptions) (*processResult, error) {
+	ctx := context.Background()
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	cmd.Stdin = strings.NewReader(options.stdin)
+	cmd.Dir = options.cwd
+
+	if len(options.env) > 0 {
+		cmd.Env = append(os.Environ(), options.env...)
+	}
+
+	// output is read from pipes rather than buffers of cmd, because cmd.Wait waits until all processes
+	// writing to its buffers exit, including children of the killed process when it times out
+	outPipe, errPipe, err := newOutputPipes(cmd)
+	if err != nil {
+		return nil, createBuiltInError(function, common.IOError, err.Error())
+	}
+
+	defer outPipe.close()
+	defer errPipe.close()
+
+	startTime := time.Now()
+	if err = cmd.Start(); err != nil {
+		return nil, createBuiltInError(function, common.IOError, err.Error())
+	}
+
+	outPipe.closeWriter()
+	errPipe.closeWriter()
+
+	err = cmd.Wait()
+
+	// processes started in background by the command may still hold the pipes after it exits
+	stdout, stdoutOk := outPipe.read(ctx)
+	stderr, stderrOk := errPipe.read(ctx)
+
+	if ctx.Err() != nil || !stdoutOk || !stderrOk {
+		return nil, createBuiltInError(function, common.IOError,
+			fmt.Sprintf("Command timed out after %v", options.timeout))
+	}
+
+	result := &processResult{
+		stdout:   stdout,
+		stderr:   stderr,
+		code:     cmd.ProcessState.ExitCode(),
+		duration: time.Since(startTime),
+	}
+
+	if options.check && result.code != 0 {
+		details := result.stderr
+		if details == "" {
+			details = err.Error()
+		}
+
+		return nil, createBuiltInError(function, common.IOError, details)
+	}
+
+	return result, nil
+}
+
+// outputPipe collects output written to pipe by process in background
+type outputPipe struct {
+	reader, writer *os.File
+	output         chan string
+}
+
+func newOutputPipes(cmd *exec.Cmd) (*outputPipe, *outputPipe, error) {
+	outPipe, err := newOutputPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	errPipe, err := newOutputPipe()
+	if err != nil {
+		outPipe.close()
+
+		return nil, nil, err
+	}
+
+	cmd.Stdout = outPipe.writer
+	cmd.Stderr = errPipe.writer
+
+	return outPipe, errPipe, nil
+}
+
+func newOutputPipe() (*outputPipe, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pipe")
+	}
+
+	pipe := &outputPipe{
+		reader: reader,
+		writer: writer,
+		output: make(chan string, 1),
+	}
+
+	go func() {
+		data, _ := io.ReadAll(reader)
+		pipe.output <- string(data)
+	}()
+
+	return pipe, nil
+}
+
+// read returns output collected from pipe, false if ctx is done before all writers close the pipe
+func (p *outputPipe) read(ctx context.Context) (string, bool) {
+	select {
+	case output := <-p.output:
+		return output, true
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// closeWriter closes writer of pipe in this process after it is passed to the started process
+func (p *outputPipe) closeWriter() {
+	_ = p.writer.Close()
+}
+
+// close stops collecting output, even if processes started by the process still hold the pipe
+func (p *outputPipe) close() {
+	_ = p.writer.Close()
+	_ = p.reader.Close()
+}
+
+func (r *processResult) toMap(function *object.JBuiltInFunction) object.JValue {
+	resMap := object.NewJMap(orderedmap.NewOrderedMap[object.JMapEntry]())
+	resMap.SetJPos(function.StartPos, function.EndPos).SetJContext(function.GetContext())
+
+	resMap.Set(object.NewJString("stdout"), object.NewJString(r.stdout).SetJContext(function.GetContext()))
+	resMap.Set(object.NewJString("stderr"), object.NewJString(r.stderr).SetJContext(function.GetContext()))
+	resMap.Set(object.NewJString("code"), object.NewJNumber(r.code).SetJContext(function.GetContext()))
+	resMap.Set(object.NewJString("duration"),
+		object.NewJNumber(r.duration.Seconds()).SetJContext(function.GetContext()))
+
+	return resMap
+}
@@ -0,0 +1,277 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/pkg/orderedmap"
+)
+
+var (
+	Dir = object.NewJBuiltInFunction("dir", []string{"value"}, ExecuteDir).SetOptionalArgCount(1).
+		SetDoc("Returns names of fields and methods of value, or names of local variables without value")
+
+	Globals = object.NewJBuiltInFunction("globals", []string{}, ExecuteGlobals).
+		SetDoc("Returns map of variables of program, built-in values are not included")
+
+	Locals = object.NewJBuiltInFunction("locals", []string{}, ExecuteLocals).
+		SetDoc("Returns map of variables of the current function or program")
+
+	Arity = object.NewJBuiltInFunction("arity", []string{"function"}, ExecuteArity).
+		SetDoc("Returns the number of args of function")
+
+	Params = object.NewJBuiltInFunction("params", []string{"function"}, ExecuteParams).
+		SetDoc("Returns names of args of function")
+
+	Source = object.NewJBuiltInFunction("source", []string{"function"}, ExecuteSource).
+		SetDoc("Returns text of definition of function")
+
+	Callable = object.NewJBuiltInFunction("callable", []string{"value"}, ExecuteCallable).
+			SetDoc("Checks whether value can be called")
+
+	Help = object.NewJBuiltInFunction("help", []string{"function"}, ExecuteHelp).
+		SetDoc("Prints signature and documentation of function")
+)
+
+// errorFields are fields of error value besides the keys of its data
+var errorFields = []string{"message", "kind", "line", "traceback", "data"}
+
+// ExecuteDir returns names of fields and methods of value: keys of map, members of enum, fields of enum member
+// and error, methods of trait and methods of impl blocks for type of value.
+// Without value it returns names of local variables
+func ExecuteDir(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if len(args) == 0 {
+		return stringsToList(function, sortedNames(localSymbolTables(function))), nil
+	}
+
+	var names []string
+
+	switch value := args[0].(type) {
+	case *object.JMap:
+		names = stringKeys(value)
+	case *object.JEnum:
+		for _, member := range value.Members {
+			names = append(names, member.Value.(string))
+		}
+	case *object.JEnumMember:
+		names = []string{"name", "value"}
+	case *object.JErrorValue:
+		names = append(names, errorFields...)
+		if data, ok := value.Data.(*object.JMap); ok {
+			names = append(names, stringKeys(data)...)
+		}
+	case *object.JTrait:
+		names = append(names, value.MethodNames...)
+	}
+
+	var methodNames []string
+
+	typeKey := object.TypeKey(args[0])
+	function.GetContext().SymbolTable.Global().Registry.Range(func(key any, _ any) bool {
+		if methodKey, ok := key.(implMethodKey); ok && methodKey.typeKey == typeKey {
+			methodNames = append(methodNames, methodKey.name)
+		}
+
+		return true
+	})
+
+	sort.Strings(methodNames)
+
+	return stringsToList(function, append(names, methodNames...)), nil
+}
+
+// ExecuteGlobals returns map of variables of program defined by scripts, built-in values are not included
+func ExecuteGlobals(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	return symbolsToMap(function, []*common.JSymbolTable{function.GetContext().SymbolTable.Global()}), nil
+}
+
+// ExecuteLocals returns map of variables visible in the current function or program, including variables of
+// enclosing blocks
+func ExecuteLocals(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	return symbolsToMap(function, localSymbolTables(function)), nil
+}
+
+func ExecuteArity(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	callValue, err := getFunctionArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewJNumber(len(callValue.ArgNames)).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteParams(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	callValue, err := getFunctionArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return stringsToList(function, callValue.ArgNames), nil
+}
+
+// ExecuteSource returns text of function definition, built-in functions have no source
+func ExecuteSource(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	callValue, ok := args[0].(*object.JFunction)
+	if !ok {
+		if _, ok := args[0].(*object.JBuiltInFunction); !ok {
+			return nil, createArgError(function, "First argument must be function")
+		}
+	}
+
+	if !ok || callValue.Source() == "" {
+		return nil, createBuiltInError(function, common.ValueError,
+			fmt.Sprintf("Source of %v is not available", args[0]))
+	}
+
+	return object.NewJString(callValue.Source()).SetJContext(function.GetContext()), nil
+}
+
+func ExecuteCallable(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	if !isCallable(args[0]) {
+		return FALSE, nil
+	}
+
+	return TRUE, nil
+}
+
+// ExecuteHelp prints signature of function followed by its documentation, which is the leading string literal
+// of function body or ## comments before the function
+func ExecuteHelp(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	callValue, err := getFunctionArg(function, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	optionalArgCount := 0
+	if builtInFunction, ok := args[0].(*object.JBuiltInFunction); ok {
+		optionalArgCount = builtInFunction.OptionalArgCount
+	}
+
+	fmt.Println(signature(callValue, optionalArgCount))
+
+	doc := callValue.Doc
+	if doc == "" {
+		doc = "No documentation"
+	}
+
+	fmt.Println("    " + strings.ReplaceAll(doc, "\n", "\n    "))
+
+	return nil, nil
+}
+
+// signature returns name and args of function with type annotations, eg. add(a: number, b) -> number,
+// the last optionalArgCount args are marked with ?
+func signature(function *object.JFunction, optionalArgCount int) string {
+	args := make([]string, len(function.ArgNames))
+	for index, argName := range function.ArgNames {
+		args[index] = argName
+		if index < len(function.ArgTypes) && function.ArgTypes[index] != "" {
+			args[index] += ": " + function.ArgTypes[index]
+		}
+
+		if index >= len(function.ArgNames)-optionalArgCount {
+			args[index] += "?"
+		}
+	}
+
+	res := fmt.Sprintf("%v(%s)", function.Value, strings.Join(args, ", "))
+	if function.ReturnType != "" {
+		res += " -> " + function.ReturnType
+	}
+
+	return res
+}
+
+// getFunctionArg returns function of user-defined or built-in function arg
+func getFunctionArg(function *object.JBuiltInFunction, arg object.JValue) (*object.JFunction, error) {
+	switch callValue := arg.(type) {
+	case *object.JFunction:
+		return callValue, nil
+	case *object.JBuiltInFunction:
+		return callValue.JFunction, nil
+	}
+
+	return nil, createArgError(function, "First argument must be function")
+}
+
+// localSymbolTables returns symbol tables of the current function or program, the innermost block is the first
+func localSymbolTables(function *object.JBuiltInFunction) []*common.JSymbolTable {
+	var symbolTables []*common.JSymbolTable
+
+	symbolTable := function.GetContext().SymbolTable
+	for ; symbolTable.IsBlock; symbolTable = symbolTable.Parent {
+		symbolTables = append(symbolTables, symbolTable)
+	}
+
+	return append(symbolTables, symbolTable)
+}
+
+// symbolsToMap returns snapshot of variables of symbol tables as map sorted by name, variables of the former
+// symbol tables hide the latter ones and built-in values are skipped
+func symbolsToMap(function *object.JBuiltInFunction, symbolTables []*common.JSymbolTable) object.JValue {
+	resMap := object.NewJMap(orderedmap.NewOrderedMap[object.JMapEntry]())
+	resMap.SetJPos(function.StartPos, function.EndPos).SetJContext(function.GetContext())
+
+	for _, name := range sortedNames(symbolTables) {
+		for _, symbolTable := range symbolTables {
+			if value, ok := symbolTable.Symbols.Get(name); ok {
+				resMap.Set(object.NewJString(name), value.(object.JValue))
+
+				break
+			}
+		}
+	}
+
+	return resMap
+}
+
+// sortedNames returns sorted names of variables of symbol tables, built-in values are skipped
+func sortedNames(symbolTables []*common.JSymbolTable) []string {
+	nameSet := make(map[string]struct{})
+
+	for _, symbolTable := range symbolTables {
+		symbolTable.Symbols.Range(func(name any, value any) bool {
+			if builtInValue, ok := builtInSymbols[name]; !ok || builtInValue != value {
+				nameSet[name.(string)] = struct{}{}
+			}
+
+			return true
+		})
+	}
+
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// stringKeys returns keys of map which are strings
+func stringKeys(value *object.JMap) []string {
+	var keys []string
+
+	value.Range(func(key, _ object.JValue) bool {
+		if name, ok := key.GetValue().(string); ok {
+			keys = append(keys, name)
+		}
+
+		return true
+	})
+
+	return keys
+}
+
+func stringsToList(function *object.JBuiltInFunction, strs []string) object.JValue {
+	elementValues := make([]object.JValue, len(strs))
+	for index, str := range strs {
+		elementValues[index] = object.NewJString(str).SetJContext(function.GetContext())
+	}
+
+	return object.NewJList(elementValues).SetJContext(function.GetContext())
+}
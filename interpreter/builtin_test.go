@@ -1,6 +1,7 @@
 package interpreter_test
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/IfanTsai/jirachi/interpreter"
@@ -9,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/pkg/orderedmap"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,6 +49,35 @@ func TestExecuteLen(t *testing.T) {
 				require.Equal(t, 5, resValue.GetValue())
 			},
 		},
+		{
+			name: "len tuple",
+			args: []object.JValue{object.NewJTuple([]object.JValue{
+				object.NewJString("localhost"),
+				object.NewJNumber(8080),
+			})},
+			checkResult: func(t *testing.T, resValue object.JValue, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJNumber(nil), resValue)
+				require.Equal(t, 2, resValue.GetValue())
+			},
+		},
+		{
+			name: "len map",
+			args: []object.JValue{func() object.JValue {
+				m := object.NewJMap(orderedmap.NewOrderedMap[object.JMapEntry]())
+				m.Set(object.NewJString("hello"), object.NewJNumber(1))
+				m.Set(object.NewJString("world"), object.NewJNumber(2))
+
+				return m
+			}()},
+			checkResult: func(t *testing.T, resValue object.JValue, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJNumber(nil), resValue)
+				require.Equal(t, 2, resValue.GetValue())
+			},
+		},
 		{
 			name: "wrong variable type",
 			args: []object.JValue{object.NewJNumber(0)},
@@ -105,6 +136,26 @@ func TestExecuteType(t *testing.T) {
 				require.Equal(t, object.List, resValue.String())
 			},
 		},
+		{
+			name: "type tuple",
+			args: []object.JValue{object.NewJTuple([]object.JValue{})},
+			checkResult: func(t *testing.T, resValue object.JValue, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJString(""), resValue)
+				require.Equal(t, object.Tuple, resValue.String())
+			},
+		},
+		{
+			name: "type map",
+			args: []object.JValue{object.NewJMap(orderedmap.NewOrderedMap[object.JMapEntry]())},
+			checkResult: func(t *testing.T, resValue object.JValue, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJString(""), resValue)
+				require.Equal(t, object.Map, resValue.String())
+			},
+		},
 		{
 			name: "type function",
 			args: []object.JValue{object.NewJFunction("test_func", []string{}, nil)},
@@ -115,6 +166,26 @@ func TestExecuteType(t *testing.T) {
 				require.Equal(t, object.Function, resValue.String())
 			},
 		},
+		{
+			name: "type null",
+			args: []object.JValue{object.NewJNull()},
+			checkResult: func(t *testing.T, resValue object.JValue, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJString(""), resValue)
+				require.Equal(t, object.Null, resValue.String())
+			},
+		},
+		{
+			name: "type enum member",
+			args: []object.JValue{object.NewJEnum("Color").AddMember("red", object.NewJNumber(0))},
+			checkResult: func(t *testing.T, resValue object.JValue, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJString(""), resValue)
+				require.Equal(t, "Color", resValue.String())
+			},
+		},
 		{
 			name: "type built-in function",
 			args: []object.JValue{interpreter.Type},
@@ -136,3 +207,23 @@ func TestExecuteType(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteReadWriteBytes(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "data.bin")
+	data := object.NewJBytes([]byte{0x00, 0x01, 0xff})
+
+	resValue, err := interpreter.ExecuteWriteBytes(interpreter.WriteBytes, []object.JValue{object.NewJString(filename), data})
+	require.NoError(t, err)
+	require.Equal(t, 3, resValue.GetValue())
+
+	resValue, err = interpreter.ExecuteReadBytes(interpreter.ReadBytes, []object.JValue{object.NewJString(filename)})
+	require.NoError(t, err)
+	require.IsType(t, object.NewJBytes(nil), resValue)
+	require.Equal(t, []byte{0x00, 0x01, 0xff}, resValue.GetValue())
+
+	_, err = interpreter.ExecuteDecode(interpreter.Decode, []object.JValue{resValue, object.NewJString("ascii")})
+	require.Error(t, err)
+	require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+}
@@ -0,0 +1,22 @@
+package interpreter
+
+import (
+	"github.com/IfanTsai/jirachi/interpreter/object"
+)
+
+var Implements = object.NewJBuiltInFunction("implements", []string{"value", "trait"}, ExecuteImplements).
+	SetDoc("Checks whether trait is implemented for type of value")
+
+// ExecuteImplements checks whether trait is implemented for type of value by impl block
+func ExecuteImplements(function *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+	trait, ok := args[1].(*object.JTrait)
+	if !ok {
+		return nil, createArgError(function, "Second argument must be trait")
+	}
+
+	if !trait.IsImplementedBy(args[0]) {
+		return FALSE, nil
+	}
+
+	return TRUE, nil
+}
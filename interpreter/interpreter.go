@@ -6,7 +6,7 @@ import (
 
 	"golang.org/x/exp/constraints"
 
-	"github.com/IfanTsai/jirachi/pkg/safemap"
+	"github.com/IfanTsai/jirachi/pkg/orderedmap"
 
 	"github.com/IfanTsai/jirachi/interpreter/object"
 
@@ -19,6 +19,10 @@ import (
 
 var GlobalSymbolTable *common.JSymbolTable
 
+// CheckTypes enables checked mode, in which type annotations of function args, return values and
+// assignments are enforced at runtime
+var CheckTypes = false
+
 func init() {
 	GlobalSymbolTable = common.NewJSymbolTable(nil).
 		Set("null", NULL).
@@ -33,10 +37,65 @@ func init() {
 		Set("is_number", IsNumber).
 		Set("is_string", IsString).
 		Set("is_list", IsList).
+		Set("is_tuple", IsTuple).
+		Set("is_map", IsMap).
+		Set("is_bytes", IsBytes).
 		Set("is_function", IsFunction).
+		Set("callable", Callable).
+		Set("dir", Dir).
+		Set("globals", Globals).
+		Set("locals", Locals).
+		Set("arity", Arity).
+		Set("params", Params).
+		Set("source", Source).
+		Set("help", Help).
+		Set("keys", Keys).
+		Set("values", Values).
+		Set("items", Items).
+		Set("has", Has).
+		Set("delete", Delete).
+		Set("merge", Merge).
+		Set("get", Get).
+		Set("copy", Copy).
+		Set("deepcopy", DeepCopy).
+		Set("encode", Encode).
+		Set("decode", Decode).
+		Set("to_hex", ToHex).
+		Set("from_hex", FromHex).
+		Set("to_base64", ToBase64).
+		Set("from_base64", FromBase64).
+		Set("read_bytes", ReadBytes).
+		Set("write_bytes", WriteBytes).
+		Set("range", Range).
+		Set("iter", Iter).
+		Set("next", Next).
+		Set("to_list", ToList).
+		Set("map", Map).
+		Set("filter", Filter).
+		Set("sum", Sum).
+		Set("error", Error).
+		Set("is_error", IsError).
+		Set("catch", Catch).
+		Set("implements", Implements).
+		Set("memoize", Memoize).
+		Set("trace", Trace).
+		Set("retry", Retry).
+		Set("eval", Eval).
+		Set("exec", Exec).
+		Set("ast_var", AstVar).
+		Set("ast_assign", AstAssign).
+		Set("ast_call", AstCall).
+		Set("ast_block", AstBlock).
 		Set("run", RunScript).
 		Set("run_shell", RunShell).
-		Set("@", RunShell)
+		Set("run_cmd", RunCmd).
+		Set("@", Shell)
+
+	GlobalSymbolTable.Symbols.Range(func(name any, value any) bool {
+		builtInSymbols[name] = value
+
+		return true
+	})
 }
 
 func Run(filename, text string) (interface{}, error) {
@@ -52,8 +111,14 @@ func Run(filename, text string) (interface{}, error) {
 		return nil, errors.WithMessage(err, "failed to parse tokens")
 	}
 
-	// run program
 	context := common.NewJContext("<program>", GlobalSymbolTable, nil, nil)
+
+	// expand macros
+	if ast, err = expandMacros(ast, context); err != nil {
+		return nil, err
+	}
+
+	// run program
 	resValue, err := NewJInterpreter(context).Interpreter(ast)
 	if err != nil {
 		return nil, err
@@ -71,6 +136,17 @@ type JInterpreter struct {
 	IsReturn   bool
 	IsBreak    bool
 	IsContinue bool
+	Label      string   // target loop of labeled break or continue
+	LoopLabels []string // labels of running loops, the innermost is the last
+	IsFunction bool     // defer is only allowed in function body
+	DeferNodes []deferredNode
+}
+
+// deferredNode is expression of defer and context which it is declared in,
+// so that let variables of the declaring block are visible when it runs
+type deferredNode struct {
+	node    parser.JNode
+	context *common.JContext
 }
 
 func NewJInterpreter(context *common.JContext) *JInterpreter {
@@ -90,6 +166,7 @@ func (i *JInterpreter) Reset() {
 	i.IsReturn = false
 	i.IsBreak = false
 	i.IsContinue = false
+	i.Label = ""
 }
 
 func (i *JInterpreter) visit(node parser.JNode) (object.JValue, error) {
@@ -102,10 +179,18 @@ func (i *JInterpreter) visit(node parser.JNode) (object.JValue, error) {
 		return i.visitNumberNode(node.(*parser.JNumberNode))
 	case parser.String:
 		return i.visitStringNode(node.(*parser.JStringNode))
+	case parser.Bytes:
+		return i.visitBytesNode(node.(*parser.JBytesNode))
 	case parser.List:
 		return i.visitListNode(node.(*parser.JListNode))
 	case parser.Map:
 		return i.visitMapNode(node.(*parser.JMapNode))
+	case parser.Tuple:
+		return i.visitTupleNode(node.(*parser.JTupleNode))
+	case parser.ListComp:
+		return i.visitListCompNode(node.(*parser.JListCompNode))
+	case parser.MapComp:
+		return i.visitMapCompNode(node.(*parser.JMapCompNode))
 	case parser.BinOp:
 		return i.visitBinOpNode(node.(*parser.JBinOpNode))
 	case parser.UnaryOp:
@@ -118,22 +203,48 @@ func (i *JInterpreter) visit(node parser.JNode) (object.JValue, error) {
 		return i.visitIfExprNode(node.(*parser.JIfExprNode))
 	case parser.ForExpr:
 		return i.visitForExprNode(node.(*parser.JForExprNode))
+	case parser.ForInExpr:
+		return i.visitForInExprNode(node.(*parser.JForInExprNode))
 	case parser.WhileExpr:
 		return i.visitWhileExprNode(node.(*parser.JWhileExprNode))
 	case parser.FuncDefExpr:
 		return i.visitFunDefNode(node.(*parser.JFuncDefNode))
-	case parser.CallExpr:
-		return i.visitCallExprNode(node.(*parser.JCallExprNode))
-	case parser.IndexExpr:
-		return i.visitIndexExprNode(node.(*parser.JIndexExprNode))
+	case parser.CallExpr, parser.IndexExpr, parser.MemberAccess, parser.SliceExpr:
+		value, _, err := i.visitChainNode(node)
+
+		return value, err
+	case parser.PipeExpr:
+		value, _, err := i.visitChainNode(node.(*parser.JPipeExprNode).CallNode)
+
+		return value, err
 	case parser.VarIndexAssign:
 		return i.visitVarIndexAssignNode(node.(*parser.JVarIndexAssignNode))
+	case parser.VarUnpackAssign:
+		return i.visitVarUnpackAssignNode(node.(*parser.JVarUnpackAssignNode))
+	case parser.VarDeclare:
+		return i.visitVarDeclareNode(node.(*parser.JVarDeclareNode))
+	case parser.ScopeDeclare:
+		return i.visitScopeDeclareNode(node.(*parser.JScopeDeclareNode))
 	case parser.ReturnExpr:
 		return i.visitReturnExprNode(node.(*parser.JReturnNode))
 	case parser.BreakExpr:
 		return i.visitBreakExprNode(node.(*parser.JBreakNode))
 	case parser.ContinueExpr:
 		return i.visitContinueExprNode(node.(*parser.JContinueNode))
+	case parser.DeferExpr:
+		return i.visitDeferExprNode(node.(*parser.JDeferNode))
+	case parser.EnumDef:
+		return i.visitEnumDefNode(node.(*parser.JEnumDefNode))
+	case parser.TraitDef:
+		return i.visitTraitDefNode(node.(*parser.JTraitDefNode))
+	case parser.ImplDef:
+		return i.visitImplDefNode(node.(*parser.JImplDefNode))
+	case parser.MacroDef:
+		return i.visitMacroDefNode(node.(*parser.JMacroDefNode))
+	case parser.Quote:
+		return i.visitQuoteNode(node.(*parser.JQuoteNode))
+	case parser.Shell:
+		return i.visitShellNode(node.(*parser.JShellNode))
 	default:
 		return nil, errors.Wrap(&common.JInvalidSyntaxError{
 			JError: &common.JError{
@@ -153,6 +264,10 @@ func (i *JInterpreter) visitStringNode(node *parser.JStringNode) (object.JValue,
 	return object.NewJString(node.Token.Value).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
 }
 
+func (i *JInterpreter) visitBytesNode(node *parser.JBytesNode) (object.JValue, error) {
+	return object.NewJBytes([]byte(node.Token.Value.(string))).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+}
+
 func (i *JInterpreter) visitListNode(node *parser.JListNode) (object.JValue, error) {
 	elementValues := make([]object.JValue, len(node.ElementNodes))
 	for index := range node.ElementNodes {
@@ -181,8 +296,9 @@ func (i *JInterpreter) visitListNode(node *parser.JListNode) (object.JValue, err
 }
 
 func (i *JInterpreter) visitMapNode(node *parser.JMapNode) (object.JValue, error) {
-	elementMap := safemap.NewSafeMap[object.JValue]()
-	for keyNode, valueNode := range node.ElementMap {
+	mapValue := object.NewJMap(orderedmap.NewOrderedMap[object.JMapEntry]())
+	for index := range node.KeyValueNodes {
+		keyNode, valueNode := node.KeyValueNodes[index][0], node.KeyValueNodes[index][1]
 		keyNodeValue, err := i.visit(keyNode)
 		if err != nil {
 			return nil, err
@@ -195,6 +311,7 @@ func (i *JInterpreter) visitMapNode(node *parser.JMapNode) (object.JValue, error
 					EndPos:   keyNodeValue.GetEndPos(),
 				},
 				Context: i.Context,
+				Kind:    common.TypeError,
 				Details: "Cannot hashed",
 			}
 		}
@@ -204,25 +321,370 @@ func (i *JInterpreter) visitMapNode(node *parser.JMapNode) (object.JValue, error
 			return nil, err
 		}
 
-		elementMap.Set(keyNodeValue.GetValue(), valueNodeValue)
+		mapValue.Set(keyNodeValue, valueNodeValue)
+	}
+
+	return mapValue.SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+}
+
+func (i *JInterpreter) visitTupleNode(node *parser.JTupleNode) (object.JValue, error) {
+	elementValues := make([]object.JValue, len(node.ElementNodes))
+	for index := range node.ElementNodes {
+		value, err := i.visit(node.ElementNodes[index])
+		if err != nil {
+			return nil, err
+		}
+
+		elementValues[index] = value
 	}
 
-	return object.NewJMap(elementMap).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+	return object.NewJTuple(elementValues).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+}
+
+func (i *JInterpreter) visitListCompNode(node *parser.JListCompNode) (object.JValue, error) {
+	compInterpreter := i.newCompInterpreter(node)
+
+	var elementValues []object.JValue
+	if err := compInterpreter.runCompClauses(node.Clauses, func() error {
+		value, err := compInterpreter.visit(node.ElementNode)
+		if err != nil {
+			return err
+		}
+
+		elementValues = append(elementValues, value)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return object.NewJList(elementValues).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+}
+
+func (i *JInterpreter) visitMapCompNode(node *parser.JMapCompNode) (object.JValue, error) {
+	compInterpreter := i.newCompInterpreter(node)
+
+	mapValue := object.NewJMap(orderedmap.NewOrderedMap[object.JMapEntry]())
+	if err := compInterpreter.runCompClauses(node.Clauses, func() error {
+		keyValue, err := compInterpreter.visit(node.KeyNode)
+		if err != nil {
+			return err
+		}
+
+		if !object.CanHashed(keyValue) {
+			return errors.Wrap(&common.JRunTimeError{
+				JError: &common.JError{
+					StartPos: node.KeyNode.GetStartPos(),
+					EndPos:   node.KeyNode.GetEndPos(),
+				},
+				Context: compInterpreter.Context,
+				Kind:    common.TypeError,
+				Details: "Cannot hashed",
+			}, "failed to visit map comprehension node")
+		}
+
+		value, err := compInterpreter.visit(node.ValueNode)
+		if err != nil {
+			return err
+		}
+
+		mapValue.Set(keyValue, value)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return mapValue.SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+}
+
+// newCompInterpreter creates interpreter with its own scope for comprehension, so that loop variables don't leak
+func (i *JInterpreter) newCompInterpreter(node parser.JNode) *JInterpreter {
+	symbolTable := common.NewJSymbolTable(i.Context.SymbolTable)
+
+	return NewJInterpreter(common.NewJContext("<comprehension>", symbolTable, i.Context, node.GetStartPos()))
+}
+
+// runCompClauses assigns loop variables of every nested clause and calls yield when all conditions are true
+func (i *JInterpreter) runCompClauses(clauses []*parser.JCompClause, yield func() error) error {
+	if len(clauses) == 0 {
+		return yield()
+	}
+
+	clause := clauses[0]
+
+	iterableValue, err := i.visit(clause.IterableNode)
+	if err != nil {
+		return err
+	}
+
+	var iterator *object.JIterator
+	if mapValue, ok := iterableValue.(*object.JMap); ok && len(clause.VarTokens) > 1 {
+		// for k, v in m iterates key value pairs of map
+		iterator = mapValue.ItemsIter()
+	} else if iterator, ok = object.Iter(iterableValue); !ok {
+		return errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: clause.IterableNode.GetStartPos(),
+				EndPos:   clause.IterableNode.GetEndPos(),
+			},
+			Context: i.Context,
+			Kind:    common.TypeError,
+			Details: fmt.Sprintf("'%s' is not iterable", object.GetJValueType(iterableValue)),
+		}, "failed to run comprehension")
+	}
+
+	for {
+		value, ok, err := iterator.Next()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if err := i.runCompIteration(clauses, value, yield); err != nil {
+			return err
+		}
+	}
+}
+
+// runCompIteration binds loop variables of the first clause to value in a new scope of the iteration,
+// so that closures created by different iterations see their own loop variables
+func (i *JInterpreter) runCompIteration(clauses []*parser.JCompClause, value object.JValue, yield func() error) error {
+	context := i.Context
+	i.Context = common.NewJContext(
+		context.Name,
+		common.NewJBlockSymbolTable(context.SymbolTable),
+		context.Parent,
+		context.ParentEntryPos,
+	)
+
+	defer func() {
+		i.Context = context
+	}()
+
+	clause := clauses[0]
+	if err := i.assignCompVars(clause, value); err != nil {
+		return err
+	}
+
+	for _, conditionNode := range clause.ConditionNodes {
+		conditionValue, err := i.visit(conditionNode)
+		if err != nil {
+			return err
+		}
+
+		if !conditionValue.IsTrue() {
+			return nil
+		}
+	}
+
+	return i.runCompClauses(clauses[1:], yield)
+}
+
+func (i *JInterpreter) assignCompVars(clause *parser.JCompClause, value object.JValue) error {
+	if len(clause.VarTokens) == 1 {
+		i.Context.SymbolTable.Set(clause.VarTokens[0].Value, value)
+
+		return nil
+	}
+
+	elementValues, ok := unpackElementValues(value)
+	if !ok || len(elementValues) != len(clause.VarTokens) {
+		firstVarToken, lastVarToken := clause.VarTokens[0], clause.VarTokens[len(clause.VarTokens)-1]
+
+		return errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: firstVarToken.StartPos,
+				EndPos:   lastVarToken.EndPos,
+			},
+			Context: i.Context,
+			Kind:    common.ValueError,
+			Details: fmt.Sprintf("Expected tuple or list of %d values to unpack", len(clause.VarTokens)),
+		}, "failed to run comprehension")
+	}
+
+	for index, varToken := range clause.VarTokens {
+		i.Context.SymbolTable.Set(varToken.Value, elementValues[index])
+	}
+
+	return nil
 }
 
 func (i *JInterpreter) visitVarAssignNode(node *parser.JVarAssignNode) (object.JValue, error) {
-	varName := node.Token.Value
+	varValue, err := i.visit(node.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.checkVarType(node.Token, node.TypeToken, varValue); err != nil {
+		return nil, err
+	}
 
+	if err := i.assignVar(node.Token, varValue); err != nil {
+		return nil, err
+	}
+
+	return varValue, nil
+}
+
+// assignVar sets value of variable by plain assignment, see common.JSymbolTable.AssignTarget
+func (i *JInterpreter) assignVar(varToken *token.JToken, varValue object.JValue) error {
+	symbolTable := i.Context.SymbolTable.AssignTarget(varToken.Value)
+	if symbolTable.IsConst(varToken.Value) {
+		return i.createConstAssignError(varToken)
+	}
+
+	symbolTable.Set(varToken.Value, varValue)
+
+	return nil
+}
+
+// visitVarDeclareNode defines variable in the current block, a const variable cannot be reassigned
+func (i *JInterpreter) visitVarDeclareNode(node *parser.JVarDeclareNode) (object.JValue, error) {
 	varValue, err := i.visit(node.Node)
 	if err != nil {
 		return nil, err
 	}
 
-	i.Context.SymbolTable.Set(varName, varValue)
+	if err := i.checkVarType(node.VarToken, node.TypeToken, varValue); err != nil {
+		return nil, err
+	}
+
+	symbolTable := i.Context.SymbolTable
+	if symbolTable.IsConst(node.VarToken.Value) {
+		return nil, i.createConstAssignError(node.VarToken)
+	}
+
+	if node.Token.Value == token.CONST {
+		symbolTable.SetConst(node.VarToken.Value, varValue)
+	} else {
+		symbolTable.Set(node.VarToken.Value, varValue)
+	}
+
+	return varValue, nil
+}
+
+// visitScopeDeclareNode makes plain assignment of variables in the current function write to the
+// program scope for global, or to the nearest enclosing function which defines them for nonlocal
+func (i *JInterpreter) visitScopeDeclareNode(node *parser.JScopeDeclareNode) (object.JValue, error) {
+	scope := i.Context.SymbolTable.Scope()
+
+	for _, varToken := range node.VarTokens {
+		target := scope.Global()
+
+		if node.Token.Value == token.NONLOCAL {
+			if scope.Parent != nil {
+				target = scope.Parent.Lookup(varToken.Value)
+			}
+
+			if target == nil || target.Parent == nil {
+				return nil, errors.Wrap(&common.JRunTimeError{
+					JError: &common.JError{
+						StartPos: varToken.StartPos,
+						EndPos:   varToken.EndPos,
+					},
+					Context: i.Context,
+					Kind:    common.NameError,
+					Details: fmt.Sprintf("no binding for nonlocal '%v' found", varToken.Value),
+				}, "failed to visit scope declaration node")
+			}
+		}
+
+		scope.DeclareOuter(varToken.Value, target)
+	}
+
+	return object.NewJNumber(nil), nil
+}
+
+func (i *JInterpreter) createConstAssignError(varToken *token.JToken) error {
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: varToken.StartPos,
+			EndPos:   varToken.EndPos,
+		},
+		Context: i.Context,
+		Kind:    common.NameError,
+		Details: fmt.Sprintf("cannot reassign const '%v'", varToken.Value),
+	}, "failed to assign variable")
+}
+
+// visitBlock visits body of if or loop in a new block scope, bind sets the loop variables of the block.
+// let and const variables of the block are dropped when it ends
+func (i *JInterpreter) visitBlock(node parser.JNode, bind func(symbolTable *common.JSymbolTable)) (object.JValue, error) {
+	context := i.Context
+	i.Context = common.NewJContext(
+		context.Name,
+		common.NewJBlockSymbolTable(context.SymbolTable),
+		context.Parent,
+		context.ParentEntryPos,
+	)
+
+	defer func() {
+		i.Context = context
+	}()
+
+	if bind != nil {
+		bind(i.Context.SymbolTable)
+	}
+
+	return i.visit(node)
+}
+
+func (i *JInterpreter) visitVarUnpackAssignNode(node *parser.JVarUnpackAssignNode) (object.JValue, error) {
+	varValue, err := i.visit(node.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	elementValues, ok := unpackElementValues(varValue)
+	if !ok {
+		return nil, errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: node.Node.GetStartPos(),
+				EndPos:   node.Node.GetEndPos(),
+			},
+			Context: i.Context,
+			Kind:    common.TypeError,
+			Details: "Only tuple or list can be unpacked",
+		}, "failed to unpack variable")
+	}
+
+	if len(elementValues) != len(node.VarTokens) {
+		return nil, errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: node.StartPos,
+				EndPos:   node.EndPos,
+			},
+			Context: i.Context,
+			Kind:    common.ValueError,
+			Details: fmt.Sprintf("Expected %d values to unpack, got %d", len(node.VarTokens), len(elementValues)),
+		}, "failed to unpack variable")
+	}
+
+	for index, varToken := range node.VarTokens {
+		if err := i.assignVar(varToken, elementValues[index]); err != nil {
+			return nil, err
+		}
+	}
 
 	return varValue, nil
 }
 
+// unpackElementValues returns elements of tuple or list, ok is false if value cannot be unpacked
+func unpackElementValues(value object.JValue) ([]object.JValue, bool) {
+	switch v := value.(type) {
+	case *object.JTuple:
+		return v.ElementValues, true
+	case *object.JList:
+		return v.ElementValues, true
+	}
+
+	return nil, false
+}
+
 func (i *JInterpreter) visitVarAccessNode(node *parser.JVarAccessNode) (object.JValue, error) {
 	varName := node.Token.Value
 	varValue := i.Context.SymbolTable.Get(varName)
@@ -233,6 +695,7 @@ func (i *JInterpreter) visitVarAccessNode(node *parser.JVarAccessNode) (object.J
 				EndPos:   node.EndPos,
 			},
 			Context: i.Context,
+			Kind:    common.NameError,
 			Details: fmt.Sprintf("'%v' is not defined", varName),
 		}, "failed to access variable")
 	}
@@ -246,6 +709,11 @@ func (i *JInterpreter) visitBinOpNode(node *parser.JBinOpNode) (object.JValue, e
 		return nil, err
 	}
 
+	// a ?? b doesn't evaluate b if a is not null
+	if node.Token.Type == token.QQ && !object.IsNull(leftValue) {
+		return leftValue, nil
+	}
+
 	rightValue, err := i.visit(node.RightNode)
 	if err != nil {
 		return nil, err
@@ -265,9 +733,20 @@ func (i *JInterpreter) visitBinOpNode(node *parser.JBinOpNode) (object.JValue, e
 	case token.POW:
 		resValue, err = leftValue.PowBy(rightValue)
 	case token.EE:
-		resValue, err = leftValue.EqualTo(rightValue)
+		if _, ok := rightValue.(*object.JNull); ok {
+			// null can be compared with any value
+			resValue, err = rightValue.EqualTo(leftValue)
+		} else {
+			resValue, err = leftValue.EqualTo(rightValue)
+		}
 	case token.NE:
-		resValue, err = leftValue.NotEqualTo(rightValue)
+		if _, ok := rightValue.(*object.JNull); ok {
+			resValue, err = rightValue.NotEqualTo(leftValue)
+		} else {
+			resValue, err = leftValue.NotEqualTo(rightValue)
+		}
+	case token.QQ:
+		resValue = rightValue
 	case token.LT:
 		resValue, err = leftValue.LessThan(rightValue)
 	case token.LTE:
@@ -276,12 +755,23 @@ func (i *JInterpreter) visitBinOpNode(node *parser.JBinOpNode) (object.JValue, e
 		resValue, err = leftValue.GreaterThan(rightValue)
 	case token.GTE:
 		resValue, err = leftValue.GreaterThanOrEqualTo(rightValue)
+	case token.DOTDOT:
+		resValue, err = object.MakeJRange(leftValue, rightValue, nil)
 	case token.KEYWORD:
 		switch node.Token.Value {
 		case token.AND:
 			resValue, err = leftValue.AndBy(rightValue)
 		case token.OR:
 			resValue, err = leftValue.OrBy(rightValue)
+		case token.IN:
+			resValue, err = rightValue.Contains(leftValue)
+		case token.IS:
+			isSame := 0
+			if object.IsSame(leftValue, rightValue) {
+				isSame = 1
+			}
+
+			resValue = object.NewJNumber(isSame).SetJContext(i.Context)
 		}
 	default:
 		return nil, errors.Wrap(&common.JInvalidSyntaxError{
@@ -330,7 +820,7 @@ func (i *JInterpreter) visitIfExprNode(node *parser.JIfExprNode) (object.JValue,
 		}
 
 		if conditionValue.IsTrue() {
-			exprValue, err := i.visit(expr)
+			exprValue, err := i.visitBlock(expr, nil)
 			if err != nil {
 				return nil, err
 			}
@@ -344,7 +834,7 @@ func (i *JInterpreter) visitIfExprNode(node *parser.JIfExprNode) (object.JValue,
 	}
 
 	if node.ElseCaseNode != nil {
-		elseValue, err := i.visit(node.ElseCaseNode)
+		elseValue, err := i.visitBlock(node.ElseCaseNode, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -361,8 +851,12 @@ func (i *JInterpreter) visitIfExprNode(node *parser.JIfExprNode) (object.JValue,
 }
 
 func (i *JInterpreter) visitWhileExprNode(node *parser.JWhileExprNode) (object.JValue, error) {
+	label, leaveLoop := i.enterLoop(node.LabelToken)
+	defer leaveLoop()
+
 	var res object.JValue
-	var resElementValues []object.JValue
+	isBroken := false
+	result := &loopResult{isBlockStatements: node.IsBlockStatements}
 
 	for {
 		condition, err := i.visit(node.ConditionNode)
@@ -374,35 +868,26 @@ func (i *JInterpreter) visitWhileExprNode(node *parser.JWhileExprNode) (object.J
 			break
 		}
 
-		res, err = i.visit(node.BodyNode)
+		res, err = i.visitBlock(node.BodyNode, nil)
 		if err != nil {
 			return nil, err
 		}
 
-		if i.IsBreak {
-			i.Reset()
+		signal := i.loopControl(label)
+		if signal == loopBreak {
+			isBroken = true
 
 			break
-		} else if i.IsContinue {
-			i.Reset()
-
+		} else if signal == loopContinue {
 			continue
-		} else if i.IsReturn {
+		} else if signal == loopExit {
 			return res, nil
 		}
 
-		resElementValues = append(resElementValues, res)
-	}
-
-	if res == nil {
-		return object.NewJNumber(nil), nil
-	}
-
-	if node.IsBlockStatements {
-		return resElementValues[len(resElementValues)-1], nil
+		result.add(res)
 	}
 
-	return object.NewJList(resElementValues).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+	return i.loopElse(node.ElseNode, isBroken, res, result, node)
 }
 
 func (i *JInterpreter) visitForExprNode(node *parser.JForExprNode) (object.JValue, error) {
@@ -466,7 +951,118 @@ func (i *JInterpreter) visitForExprNode(node *parser.JForExprNode) (object.JValu
 	return executeForLoop(i, node, start, step, end)
 }
 
+func (i *JInterpreter) visitForInExprNode(node *parser.JForInExprNode) (object.JValue, error) {
+	iterableValue, err := i.visit(node.IterableNode)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, ok := object.Iter(iterableValue)
+	if !ok {
+		return nil, errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: node.IterableNode.GetStartPos(),
+				EndPos:   node.IterableNode.GetEndPos(),
+			},
+			Context: i.Context,
+			Kind:    common.TypeError,
+			Details: fmt.Sprintf("'%s' is not iterable", object.GetJValueType(iterableValue)),
+		}, "failed to visit for in expression node")
+	}
+
+	label, leaveLoop := i.enterLoop(node.LabelToken)
+	defer leaveLoop()
+
+	var res object.JValue
+	isBroken := false
+	result := &loopResult{isBlockStatements: node.IsBlockStatements}
+
+	for {
+		value, ok, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		res, err = i.visitBlock(node.BodyNode, func(symbolTable *common.JSymbolTable) {
+			symbolTable.Set(node.Token.Value, value)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		signal := i.loopControl(label)
+		if signal == loopBreak {
+			isBroken = true
+
+			break
+		} else if signal == loopContinue {
+			continue
+		} else if signal == loopExit {
+			return res, nil
+		}
+
+		result.add(res)
+	}
+
+	return i.loopElse(node.ElseNode, isBroken, res, result, node)
+}
+
 func (i *JInterpreter) visitFunDefNode(node *parser.JFuncDefNode) (object.JValue, error) {
+	functionValue, err := i.newFunction(node)
+	if err != nil {
+		return nil, err
+	}
+
+	resValue, err := i.decorate(node, functionValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Token != nil {
+		if err := i.assignVar(node.Token, resValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return resValue, nil
+}
+
+// decorate passes function through decorators of node, which are evaluated from top to bottom
+// and applied from bottom to top, so the nearest decorator wraps the function first
+func (i *JInterpreter) decorate(node *parser.JFuncDefNode, function object.JValue) (object.JValue, error) {
+	decorators := make([]object.JValue, len(node.DecoratorNodes))
+	for index, decoratorNode := range node.DecoratorNodes {
+		decorator, err := i.visit(decoratorNode)
+		if err != nil {
+			return nil, err
+		}
+
+		decorators[index] = decorator
+	}
+
+	resValue := function
+	for index := len(decorators) - 1; index >= 0; index-- {
+		decorated, err := callFunction(decorators[index], []object.JValue{resValue})
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to apply decorator")
+		}
+
+		if decorated == nil {
+			decorated = object.NewJNull()
+		}
+
+		resValue = decorated
+	}
+
+	return resValue, nil
+}
+
+// newFunction creates function defined by node in the current context
+func (i *JInterpreter) newFunction(node *parser.JFuncDefNode) (*object.JFunction, error) {
 	argNames := make([]string, len(node.ArgTokens))
 	var ok bool
 	for index := range node.ArgTokens {
@@ -477,6 +1073,7 @@ func (i *JInterpreter) visitFunDefNode(node *parser.JFuncDefNode) (object.JValue
 					EndPos:   node.EndPos,
 				},
 				Context: i.Context,
+				Kind:    common.TypeError,
 				Details: "arg token object is not string",
 			}, "failed to visit function definition node")
 		}
@@ -487,51 +1084,281 @@ func (i *JInterpreter) visitFunDefNode(node *parser.JFuncDefNode) (object.JValue
 		funcName = node.Token.Value
 	}
 
-	functionValue := object.NewJFunction(funcName, argNames, node.BodyNode).
+	argTypes := make([]string, len(node.ArgTypeTokens))
+	for index, typeToken := range node.ArgTypeTokens {
+		if typeToken != nil {
+			argTypes[index] = typeToken.Value.(string)
+		}
+	}
+
+	returnType := ""
+	if node.ReturnTypeToken != nil {
+		returnType = node.ReturnTypeToken.Value.(string)
+	}
+
+	functionValue := object.NewJFunction(funcName, argNames, node.BodyNode).SetTypes(argTypes, returnType)
+	functionValue.Closure = i.Context.SymbolTable
+	functionValue.SourceStartPos, functionValue.SourceEndPos = node.StartPos, node.EndPos
+	functionValue.Doc = node.Doc
+	functionValue.SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context)
+
+	return functionValue, nil
+}
+
+// visitEnumDefNode creates enum and assigns it to its name, members without explicit value are numbered
+// from 0 or from the previous integer value plus 1
+func (i *JInterpreter) visitEnumDefNode(node *parser.JEnumDefNode) (object.JValue, error) {
+	enum := object.NewJEnum(node.Token.Value.(string)).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context)
+	nextValue := 0
+
+	for index, memberToken := range node.MemberTokens {
+		var memberValue object.JValue = object.NewJNumber(nextValue).
+			SetJPos(memberToken.StartPos, memberToken.EndPos).
+			SetJContext(i.Context)
+
+		if valueNode := node.ValueNodes[index]; valueNode != nil {
+			value, err := i.visit(valueNode)
+			if err != nil {
+				return nil, err
+			}
+
+			memberValue = value
+		}
+
+		if value, ok := memberValue.GetValue().(int); ok {
+			nextValue = value
+		}
+
+		nextValue++
+
+		enum.(*object.JEnum).AddMember(memberToken.Value.(string), memberValue).
+			SetJPos(memberToken.StartPos, memberToken.EndPos)
+	}
+
+	if err := i.assignVar(node.Token, enum); err != nil {
+		return nil, err
+	}
+
+	return enum, nil
+}
+
+// visitTraitDefNode creates trait and assigns it to its name
+func (i *JInterpreter) visitTraitDefNode(node *parser.JTraitDefNode) (object.JValue, error) {
+	methodNames := make([]string, len(node.MethodTokens))
+	methodArgNames := make([][]string, len(node.MethodTokens))
+
+	for index, methodToken := range node.MethodTokens {
+		methodNames[index] = methodToken.Value.(string)
+		methodArgNames[index] = make([]string, len(node.MethodArgTokens[index]))
+
+		for argIndex, argToken := range node.MethodArgTokens[index] {
+			methodArgNames[index][argIndex] = argToken.Value.(string)
+		}
+	}
+
+	trait := object.NewJTrait(node.Token.Value.(string), methodNames, methodArgNames).
 		SetJPos(node.StartPos, node.EndPos).
 		SetJContext(i.Context)
 
-	if funcName != nil {
-		i.Context.SymbolTable.Set(funcName, functionValue)
+	if err := i.assignVar(node.Token, trait); err != nil {
+		return nil, err
 	}
 
-	return functionValue, nil
+	return trait, nil
+}
+
+// implMethodKey is key of method defined by impl block in registry of program symbol table, see object.TypeKey.
+// Methods can be called as value.method(args) by the program, eval or exec source which defines them
+type implMethodKey struct {
+	typeKey any
+	name    string
 }
 
-func (i *JInterpreter) visitCallExprNode(node *parser.JCallExprNode) (object.JValue, error) {
-	callValue, err := i.visit(node.CallNode)
+// visitImplDefNode checks that impl block defines all methods of trait with the same number of args,
+// then registers the methods for the target, which is name of type or enum
+func (i *JInterpreter) visitImplDefNode(node *parser.JImplDefNode) (object.JValue, error) {
+	traitValue, err := i.visit(&parser.JVarAccessNode{JBaseNode: &parser.JBaseNode{
+		Token:    node.Token,
+		StartPos: node.Token.StartPos,
+		EndPos:   node.Token.EndPos,
+	}})
 	if err != nil {
 		return nil, err
 	}
 
-	argValues := make([]object.JValue, len(node.ArgNodes))
-	for index := range node.ArgNodes {
-		argValue, err := i.visit(node.ArgNodes[index])
-		if err != nil {
-			return nil, err
+	trait, ok := traitValue.(*object.JTrait)
+	if !ok {
+		return nil, i.createImplError(node.Token, common.TypeError, fmt.Sprintf("'%v' is not a trait", node.Token.Value))
+	}
+
+	typeKey, err := i.getImplTypeKey(node.TargetToken)
+	if err != nil {
+		return nil, err
+	}
+
+	methodNodes := make(map[any]*parser.JFuncDefNode, len(node.MethodNodes))
+	for _, methodNode := range node.MethodNodes {
+		methodNodes[methodNode.Token.Value] = methodNode
+	}
+
+	for index, methodName := range trait.MethodNames {
+		methodNode, ok := methodNodes[methodName]
+		if !ok {
+			return nil, i.createImplError(node.TargetToken, common.TypeError,
+				fmt.Sprintf("impl %v for %v is missing method '%s'", trait.Value, node.TargetToken.Value, methodName))
 		}
-		argValues[index] = argValue
+
+		if len(methodNode.ArgTokens) != len(trait.MethodArgNames[index]) {
+			return nil, i.createImplError(methodNode.Token, common.TypeError, fmt.Sprintf("method '%s' of %v must take %d args, got %d",
+				methodName, trait.Value, len(trait.MethodArgNames[index]), len(methodNode.ArgTokens)))
+		}
+
+		delete(methodNodes, methodName)
 	}
 
-	if function, ok := callValue.(*object.JFunction); ok {
-		return executeFunction(function, argValues)
-	} else {
-		// run if function is built-in
-		returnValue, err := callValue.Execute(argValues)
+	for _, methodNode := range node.MethodNodes {
+		if _, ok := methodNodes[methodNode.Token.Value]; ok {
+			return nil, i.createImplError(methodNode.Token, common.NameError,
+				fmt.Sprintf("'%v' is not a method of trait %v", methodNode.Token.Value, trait.Value))
+		}
+	}
+
+	for _, methodNode := range node.MethodNodes {
+		method, err := i.newFunction(methodNode)
 		if err != nil {
-			return nil, errors.WithMessage(err, "failed to visit call expression node")
+			return nil, err
 		}
 
-		return returnValue, nil
+		methodKey := implMethodKey{typeKey: typeKey, name: method.Value.(string)}
+		i.Context.SymbolTable.Global().Registry.Set(methodKey, method)
 	}
+
+	trait.AddImpl(typeKey)
+
+	return trait, nil
 }
 
-func (i *JInterpreter) visitIndexExprNode(node *parser.JIndexExprNode) (object.JValue, error) {
-	indexNodeValue, err := i.visit(node.IndexNode)
+// getImplTypeKey returns type key of impl block target, which is name of type or variable of enum
+func (i *JInterpreter) getImplTypeKey(targetToken *token.JToken) (any, error) {
+	targetName := targetToken.Value.(string)
+	if parser.IsTypeName(targetName) && targetName != object.Any {
+		return targetName, nil
+	}
+
+	targetValue, err := i.visit(&parser.JVarAccessNode{JBaseNode: &parser.JBaseNode{
+		Token:    targetToken,
+		StartPos: targetToken.StartPos,
+		EndPos:   targetToken.EndPos,
+	}})
 	if err != nil {
 		return nil, err
 	}
 
+	enum, ok := targetValue.(*object.JEnum)
+	if !ok {
+		return nil, i.createImplError(targetToken, common.TypeError,
+			fmt.Sprintf("'%s' is not an enum or type, cannot implement trait for it", targetName))
+	}
+
+	return enum, nil
+}
+
+func (i *JInterpreter) createImplError(errToken *token.JToken, kind, details string) error {
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: errToken.StartPos,
+			EndPos:   errToken.EndPos,
+		},
+		Context: i.Context,
+		Kind:    kind,
+		Details: details,
+	}, "failed to visit impl block")
+}
+
+// getImplMethod returns method named name implemented for type of value by program of context,
+// self is bound to value
+func getImplMethod(context *common.JContext, value object.JValue, name string) (object.JValue, bool) {
+	methodKey := implMethodKey{typeKey: object.TypeKey(value), name: name}
+
+	registeredMethod, ok := context.SymbolTable.Global().Registry.Get(methodKey)
+	if !ok {
+		return nil, false
+	}
+
+	method := registeredMethod.(*object.JFunction)
+
+	boundMethod := object.NewJBuiltInFunction(method.Value, method.ArgNames[1:],
+		func(_ *object.JBuiltInFunction, args []object.JValue) (object.JValue, error) {
+			return callFunction(method, append([]object.JValue{value}, args...))
+		}).SetDoc(method.Doc)
+
+	return boundMethod, true
+}
+
+// visitChainNode visits a link of a postfix chain such as a?.b.c(d)[e], the result is null without visiting
+// the rest of the chain once a null-safe link finds null, and isShortCircuited reports whether it happened
+func (i *JInterpreter) visitChainNode(node parser.JNode) (value object.JValue, isShortCircuited bool, err error) {
+	var receiverNode parser.JNode
+
+	isNullSafe := false
+
+	switch node := node.(type) {
+	case *parser.JCallExprNode:
+		receiverNode = node.CallNode
+	case *parser.JIndexExprNode:
+		receiverNode, isNullSafe = node.IndexNode, node.IsNullSafe
+	case *parser.JMemberAccessNode:
+		receiverNode, isNullSafe = node.Node, node.IsNullSafe
+	case *parser.JSliceExprNode:
+		receiverNode = node.SliceNode
+	default:
+		value, err = i.visit(node)
+
+		return value, false, err
+	}
+
+	receiver, isShortCircuited, err := i.visitChainNode(receiverNode)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if isShortCircuited || isNullSafe && object.IsNull(receiver) {
+		return object.NewJNull().SetJPos(node.GetStartPos(), node.GetEndPos()).SetJContext(i.Context), true, nil
+	}
+
+	switch node := node.(type) {
+	case *parser.JCallExprNode:
+		value, err = i.visitCallExprNode(node, receiver)
+	case *parser.JIndexExprNode:
+		value, err = i.visitIndexExprNode(node, receiver)
+	case *parser.JMemberAccessNode:
+		value, err = i.visitMemberAccessNode(node, receiver)
+	case *parser.JSliceExprNode:
+		value, err = i.visitSliceExprNode(node, receiver)
+	}
+
+	return value, false, err
+}
+
+func (i *JInterpreter) visitCallExprNode(node *parser.JCallExprNode, callValue object.JValue) (object.JValue, error) {
+	argValues := make([]object.JValue, len(node.ArgNodes))
+	for index := range node.ArgNodes {
+		argValue, err := i.visit(node.ArgNodes[index])
+		if err != nil {
+			return nil, err
+		}
+		argValues[index] = argValue
+	}
+
+	returnValue, err := callFunction(callValue, argValues)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to visit call expression node")
+	}
+
+	return returnValue, nil
+}
+
+func (i *JInterpreter) visitIndexExprNode(node *parser.JIndexExprNode, indexNodeValue object.JValue) (object.JValue, error) {
 	indexExprValue, err := i.visit(node.IndexExpr)
 	if err != nil {
 		return nil, err
@@ -545,6 +1372,42 @@ func (i *JInterpreter) visitIndexExprNode(node *parser.JIndexExprNode) (object.J
 	return resValue, nil
 }
 
+// visitMemberAccessNode visits a.b, which is method b implemented for type of a or indexes a with string "b"
+func (i *JInterpreter) visitMemberAccessNode(node *parser.JMemberAccessNode, value object.JValue) (object.JValue, error) {
+	// methods of impl blocks are looked up before fields
+	if method, ok := getImplMethod(i.Context, value, node.Token.Value.(string)); ok {
+		return method.SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+	}
+
+	memberName := object.NewJString(node.Token.Value).SetJPos(node.Token.StartPos, node.Token.EndPos).SetJContext(i.Context)
+
+	resValue, err := value.IndexAccess(memberName)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to visit member access node")
+	}
+
+	return resValue, nil
+}
+
+func (i *JInterpreter) visitSliceExprNode(node *parser.JSliceExprNode, sliceNodeValue object.JValue) (object.JValue, error) {
+	startValue, err := i.visit(node.StartExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	endValue, err := i.visit(node.EndExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	resValue, err := sliceNodeValue.SliceAccess(startValue, endValue)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to visit slice expression node")
+	}
+
+	return resValue.SetJPos(node.StartPos, node.EndPos), nil
+}
+
 func (i *JInterpreter) visitVarIndexAssignNode(node *parser.JVarIndexAssignNode) (object.JValue, error) {
 	indexNodeValue, err := i.visit(node.IndexExprNode.IndexNode)
 	if err != nil {
@@ -580,20 +1443,116 @@ func (i *JInterpreter) visitReturnExprNode(node *parser.JReturnNode) (object.JVa
 	return resValue, nil
 }
 
-func (i *JInterpreter) visitBreakExprNode(*parser.JBreakNode) (object.JValue, error) {
+func (i *JInterpreter) visitBreakExprNode(node *parser.JBreakNode) (object.JValue, error) {
+	if err := i.setLoopControlLabel(node.LabelToken); err != nil {
+		return nil, errors.WithMessage(err, "failed to visit break node")
+	}
+
 	i.IsBreak = true
 
 	return nil, nil
 }
 
-func (i *JInterpreter) visitContinueExprNode(*parser.JContinueNode) (object.JValue, error) {
+func (i *JInterpreter) visitContinueExprNode(node *parser.JContinueNode) (object.JValue, error) {
+	if err := i.setLoopControlLabel(node.LabelToken); err != nil {
+		return nil, errors.WithMessage(err, "failed to visit continue node")
+	}
+
 	i.IsContinue = true
 
 	return nil, nil
 }
 
+func (i *JInterpreter) visitDeferExprNode(node *parser.JDeferNode) (object.JValue, error) {
+	if !i.IsFunction {
+		return nil, errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: node.StartPos,
+				EndPos:   node.EndPos,
+			},
+			Context: i.Context,
+			Kind:    common.SyntaxError,
+			Details: "defer is only allowed in function",
+		}, "failed to visit defer node")
+	}
+
+	i.DeferNodes = append(i.DeferNodes, deferredNode{node: node.DeferNode, context: i.Context})
+
+	return nil, nil
+}
+
+// runDeferNodes visits deferred expressions in LIFO order after function body returns with resValue or err,
+// all deferred expressions are visited even if some of them fail, and their errors are chained onto err
+func (i *JInterpreter) runDeferNodes(resValue object.JValue, err error) (object.JValue, error) {
+	context := i.Context
+
+	for index := len(i.DeferNodes) - 1; index >= 0; index-- {
+		i.Reset()
+		i.Context = i.DeferNodes[index].context
+
+		if _, deferErr := i.visit(i.DeferNodes[index].node); deferErr != nil {
+			if err == nil {
+				err = deferErr
+			} else {
+				deferErrValue := object.MakeJErrorValue(deferErr)
+				err = errors.WithMessagef(err, "deferred expression failed too, %s: %v",
+					deferErrValue.Kind, deferErrValue.Value)
+			}
+		}
+	}
+
+	i.Context = context
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resValue, nil
+}
+
+// setLoopControlLabel sets the target loop of break or continue, the label must be of a running loop
+func (i *JInterpreter) setLoopControlLabel(labelToken *token.JToken) error {
+	if labelToken == nil {
+		return nil
+	}
+
+	label := labelToken.Value.(string)
+	for _, loopLabel := range i.LoopLabels {
+		if loopLabel == label {
+			i.Label = label
+
+			return nil
+		}
+	}
+
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: labelToken.StartPos,
+			EndPos:   labelToken.EndPos,
+		},
+		Context: i.Context,
+		Kind:    common.SyntaxError,
+		Details: fmt.Sprintf("label '%s' is not defined", label),
+	}, "failed to find loop label")
+}
+
+// callFunction calls user-defined function or built-in function
+func callFunction(callValue object.JValue, argValues []object.JValue) (object.JValue, error) {
+	if function, ok := callValue.(*object.JFunction); ok {
+		return executeFunction(function, argValues)
+	}
+
+	return callValue.Execute(argValues)
+}
+
 func executeFunction(function *object.JFunction, argValues []object.JValue) (object.JValue, error) {
-	symbolTable := common.NewJSymbolTable(function.GetContext().SymbolTable)
+	// variables are looked up in the scope which function is defined in, so that it can be closure
+	outerSymbolTable := function.GetContext().SymbolTable
+	if function.Closure != nil {
+		outerSymbolTable = function.Closure
+	}
+
+	symbolTable := common.NewJSymbolTable(outerSymbolTable)
 	newContext := common.NewJContext(function.GetValue().(string), symbolTable, function.GetContext(), function.GetStartPos())
 
 	if err := function.CheckArgs(argValues); err != nil {
@@ -603,11 +1562,76 @@ func executeFunction(function *object.JFunction, argValues []object.JValue) (obj
 	for index := range argValues {
 		argName := function.ArgNames[index]
 		argValue := argValues[index]
+
+		if CheckTypes && index < len(function.ArgTypes) && function.ArgTypes[index] != "" &&
+			!object.IsType(argValue, function.ArgTypes[index]) {
+			return nil, createTypeError(argValue, function, fmt.Sprintf("argument '%s' of %v must be %s, got %s",
+				argName, function.Value, function.ArgTypes[index], getTypeName(argValue)))
+		}
+
 		argValue.SetJContext(newContext)
 		newContext.SymbolTable.Set(argName, argValue)
 	}
 
-	return NewJInterpreter(newContext).visit(function.BodyNode)
+	interpreter := NewJInterpreter(newContext)
+	interpreter.IsFunction = true
+	resValue, err := interpreter.visit(function.BodyNode)
+
+	resValue, err = interpreter.runDeferNodes(resValue, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if CheckTypes && function.ReturnType != "" && !object.IsType(resValue, function.ReturnType) {
+		return nil, createTypeError(resValue, function, fmt.Sprintf("return value of %v must be %s, got %s",
+			function.Value, function.ReturnType, getTypeName(resValue)))
+	}
+
+	return resValue, nil
+}
+
+// checkVarType checks value of variable against its type annotation in checked mode
+func (i *JInterpreter) checkVarType(varToken, typeToken *token.JToken, varValue object.JValue) error {
+	if !CheckTypes || typeToken == nil || object.IsType(varValue, typeToken.Value.(string)) {
+		return nil
+	}
+
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: varToken.StartPos,
+			EndPos:   varToken.EndPos,
+		},
+		Context: i.Context,
+		Kind:    common.TypeError,
+		Details: fmt.Sprintf("variable '%v' must be %v, got %s", varToken.Value, typeToken.Value, getTypeName(varValue)),
+	}, "failed to check variable type")
+}
+
+// createTypeError creates type error at value, or at function if value has no position
+func createTypeError(value object.JValue, function *object.JFunction, details string) error {
+	pos := value
+	if pos == nil || pos.GetStartPos() == nil {
+		pos = function
+	}
+
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: pos.GetStartPos(),
+			EndPos:   pos.GetEndPos(),
+		},
+		Context: function.GetContext(),
+		Kind:    common.TypeError,
+		Details: details,
+	}, "failed to check type")
+}
+
+// getTypeName returns type name of value used by type error, null value is named null
+func getTypeName(value object.JValue) string {
+	if value == nil || object.IsNull(value) {
+		return "null"
+	}
+
+	return object.GetJValueType(value)
 }
 
 func executeForLoop[T constraints.Integer | constraints.Float](
@@ -615,44 +1639,136 @@ func executeForLoop[T constraints.Integer | constraints.Float](
 	node *parser.JForExprNode,
 	start, step, end T,
 ) (object.JValue, error) {
-	var resElementValues []object.JValue
+	label, leaveLoop := i.enterLoop(node.LabelToken)
+	defer leaveLoop()
+
 	var res object.JValue
 	var err error
+	isBroken := false
+	result := &loopResult{isBlockStatements: node.IsBlockStatements}
 
 	for j := start; ; j += step {
 		if (step > 0 && j >= end) || (step < 0 && j <= end) {
 			break
 		}
 
-		i.Context.SymbolTable.Set(node.Token.Value, object.NewJNumber(j))
-
-		res, err = i.visit(node.BodyNode)
+		res, err = i.visitBlock(node.BodyNode, func(symbolTable *common.JSymbolTable) {
+			symbolTable.Set(node.Token.Value, object.NewJNumber(j))
+		})
 		if err != nil {
 			return nil, err
 		}
 
-		if i.IsBreak {
-			i.Reset()
+		signal := i.loopControl(label)
+		if signal == loopBreak {
+			isBroken = true
 
 			break
-		} else if i.IsContinue {
-			i.Reset()
-
+		} else if signal == loopContinue {
 			continue
-		} else if i.IsReturn {
+		} else if signal == loopExit {
 			return res, nil
 		}
 
-		resElementValues = append(resElementValues, res)
+		result.add(res)
+	}
+
+	return i.loopElse(node.ElseNode, isBroken, res, result, node)
+}
+
+type loopSignal int
+
+const (
+	loopNext loopSignal = iota
+	loopContinue
+	loopBreak
+	loopExit // return, or break and continue of an outer loop, the flags are kept for the outer
+)
+
+// enterLoop pushes label of loop, the returned function must be called when the loop ends
+func (i *JInterpreter) enterLoop(labelToken *token.JToken) (string, func()) {
+	label := ""
+	if labelToken != nil {
+		label = labelToken.Value.(string)
+	}
+
+	i.LoopLabels = append(i.LoopLabels, label)
+
+	return label, func() {
+		i.LoopLabels = i.LoopLabels[:len(i.LoopLabels)-1]
+	}
+}
+
+// loopControl handles break, continue and return after body of loop labeled label is visited
+func (i *JInterpreter) loopControl(label string) loopSignal {
+	if i.IsReturn || (i.Label != "" && i.Label != label) {
+		return loopExit
+	}
+
+	if i.IsBreak {
+		i.Reset()
+
+		return loopBreak
+	}
+
+	if i.IsContinue {
+		i.Reset()
+
+		return loopContinue
+	}
+
+	return loopNext
+}
+
+// loopElse visits else clause of loop if the loop isn't broken, the value of loop is kept
+func (i *JInterpreter) loopElse(
+	elseNode parser.JNode,
+	isBroken bool,
+	res object.JValue,
+	result *loopResult,
+	node parser.JNode,
+) (object.JValue, error) {
+	if elseNode != nil && !isBroken {
+		elseValue, err := i.visitBlock(elseNode, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if i.IsReturn || i.IsBreak || i.IsContinue {
+			return elseValue, nil
+		}
 	}
 
 	if res == nil {
 		return object.NewJNumber(nil), nil
 	}
 
-	if node.IsBlockStatements {
-		return resElementValues[len(resElementValues)-1], nil
+	return result.value(i, node), nil
+}
+
+// loopResult collects values of loop body, only the last value is kept
+// when the body is block statements, so that loop statements don't build a list
+type loopResult struct {
+	isBlockStatements bool
+	elementValues     []object.JValue
+}
+
+func (r *loopResult) add(value object.JValue) {
+	if r.isBlockStatements {
+		r.elementValues = r.elementValues[:0]
+	}
+
+	r.elementValues = append(r.elementValues, value)
+}
+
+func (r *loopResult) value(i *JInterpreter, node parser.JNode) object.JValue {
+	if r.isBlockStatements {
+		if len(r.elementValues) == 0 {
+			return object.NewJNumber(nil)
+		}
+
+		return r.elementValues[0]
 	}
 
-	return object.NewJList(resElementValues).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+	return object.NewJList(r.elementValues).SetJPos(node.GetStartPos(), node.GetEndPos()).SetJContext(i.Context)
 }
@@ -137,6 +137,1008 @@ func TestRun(t *testing.T) {
 
 			},
 		},
+		{
+			name: "tuple as map key",
+			source: `
+				addr = ("localhost", 8080)
+				m = {addr: "web", ("localhost", 5432): "db"}
+				m[("localhost", 5432)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Len(t, resValue.(*object.JList).ElementValues, 3)
+				require.Equal(t, "db", resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "tuple unpack and slice",
+			source: `
+				t = (1, 2, 3, 4)
+				host, port = t[1:3]
+				(host, port, (host,), ())
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Len(t, resValue.(*object.JList).ElementValues, 3)
+				require.Equal(t, "(2, 3, (2,), ())", resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "tuple compare",
+			source: `
+				[(1, 2) == (1, 2), (1, "a") == (1, 2), (1, 2) < (1, 3), ("b",) > ("a", 1), (1, 2) < (1, 2, 0)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[1, 0, 1, 1, 1]", resValue.(*object.JList).String())
+			},
+		},
+		{
+			name: "tuple is immutable",
+			source: `
+				t = (1, 2)
+				t[0] = 3
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "tuple is immutable")
+			},
+		},
+		{
+			name: "map keeps insertion order",
+			source: `
+				m = {"z": 1, "a": 2, "m": 3}
+				m["b"] = 4
+				m["z"] = 5
+				m["a"] = null
+				m
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Len(t, resValue.(*object.JList).ElementValues, 5)
+				require.Equal(t, "{z: 5, a: <null>, m: 3, b: 4}", resValue.(*object.JList).ElementValues[4].String())
+			},
+		},
+		{
+			name: "map builtins",
+			source: `
+				m = {"host": "localhost", "port": 80}
+				deleted = delete(m, "host")
+				[keys(m), values(m), items(m), has(m, "port"), has(m, "host"), deleted, get(m, "user", "root"), merge(m, {"port": 8080, "tls": 1})]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Len(t, resValue.(*object.JList).ElementValues, 3)
+				require.Equal(t,
+					"[[port], [80], [(port, 80)], 1, 0, localhost, root, {port: 8080, tls: 1}]",
+					resValue.(*object.JList).ElementValues[2].String(),
+				)
+			},
+		},
+		{
+			name: "list and map are shared by reference",
+			source: `
+				a = [1, 2]
+				b = a
+				b[0] = 3
+				m = {"list": a}
+				n = m
+				n["new"] = 1
+				[a, m]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Len(t, resValue.(*object.JList).ElementValues, 7)
+				require.Equal(t, "[[3, 2], {list: [3, 2], new: 1}]", resValue.(*object.JList).ElementValues[6].String())
+			},
+		},
+		{
+			name: "operators build new list",
+			source: `
+				a = [[1], 2]
+				b = a + 3
+				b[1] = 0
+				c = a * 2
+				c[0] = 0
+				inner = b[0]
+				inner[0] = 5
+				[a, b, c]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[[[5], 2], [[5], 0, 3], [0, 2, [5], 2]]",
+					resValue.(*object.JList).ElementValues[len(resValue.(*object.JList).ElementValues)-1].String(),
+				)
+			},
+		},
+		{
+			name: "copy and deepcopy",
+			source: `
+				a = [[1], {"k": [2]}]
+				shallow = copy(a)
+				deep = deepcopy(a)
+				shallow[0] = "replaced"
+				inner = a[0]
+				inner[0] = 10
+				m = a[1]
+				m["k"] = 20
+				self = {}
+				self["self"] = self
+				cyclic = deepcopy(self)
+				[a, shallow, deep, keys(cyclic)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[[[10], {k: 20}], [replaced, {k: 20}], [[1], {k: [2]}], [self]]",
+					resValue.(*object.JList).ElementValues[len(resValue.(*object.JList).ElementValues)-1].String(),
+				)
+			},
+		},
+		{
+			name: "bytes",
+			source: `
+				data = b"\x00\x01ab" + b"\xff"
+				[data, len(data), data[1], data[4], data[2:4], data == b"\x00\x01ab\xff", type(data)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					`[b"\x00\x01ab\xff", 5, 1, 255, b"ab", 1, bytes]`,
+					resValue.(*object.JList).ElementValues[1].String(),
+				)
+			},
+		},
+		{
+			name: "bytes conversion",
+			source: `
+				data = encode("héllo", "utf-8")
+				[data, decode(data, "utf-8"), encode("é", "latin-1"), to_hex(b"\x01\xab"), from_hex("01ab"), to_base64(b"hi"), from_base64("aGk=")]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					`[b"h\xc3\xa9llo", héllo, b"\xe9", 01ab, b"\x01\xab", aGk=, b"hi"]`,
+					resValue.(*object.JList).ElementValues[1].String(),
+				)
+			},
+		},
+		{
+			name: "range and for in",
+			source: `
+				total = 0
+				for i in 1..5 then
+					if i == 3 then continue
+					total = total + i
+				end
+				r = range(10, 0, -3)
+				[total, r, len(r), r[1], for c in "ab" then c + c, for k in {"x": 1, "y": 2} then k]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[7, range(10, 0, -3), 4, 7, [aa, bb], [x, y]]",
+					resValue.(*object.JList).ElementValues[3].String(),
+				)
+			},
+		},
+		{
+			name: "iterator",
+			source: `
+				it = iter([1, 2])
+				[next(it), next(it), next(it, "done"), to_list(filter(map(0..5, fun(x) -> x * 2), fun(x) -> x > 4)), sum(0..1000000)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[1, 2, done, [6, 8], 499999500000]",
+					resValue.(*object.JList).ElementValues[1].String(),
+				)
+			},
+		},
+		{
+			name: "exhausted iterator",
+			source: `
+				it = iter("")
+				next(it)
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "Iterator is exhausted")
+			},
+		},
+		{
+			name: "for in not iterable",
+			source: `
+				for i in 1 then i
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "'number' is not iterable")
+			},
+		},
+		{
+			name: "comprehension",
+			source: `
+				xs = [1, 2, 3, 4]
+				m = {"a": 1, "b": 2, "c": 3}
+				[[x * 2 for x in xs if x > 1 if x < 4], [(x, y) for x in 1..3 for y in x..3], {k: v * 10 for k, v in m if v != 2}]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[[4, 6], [(1, 1), (1, 2), (2, 2)], {a: 10, c: 30}]",
+					resValue.(*object.JList).ElementValues[2].String(),
+				)
+			},
+		},
+		{
+			name: "comprehension variable does not leak",
+			source: `
+				ys = [y for y in 0..3]
+				y
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "'y' is not defined")
+			},
+		},
+		{
+			name: "comprehension closures capture variable of each iteration",
+			source: `
+				comp_fns = [fun() -> i for i in 0..3]
+				comp_pair_fns = {k: fun() -> k + v for k, v in {"a": "1", "b": "2"}}
+				[comp_fns[0](), comp_fns[2](), comp_pair_fns["a"]()]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[0, 2, a1]", resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "membership",
+			source: `
+				xs = [1, "a", (1, 2)]
+				m = {"k": 1}
+				[(1, 2) in xs, 3 not in xs, "ell" in "hello", "k" in m, "x" in m, 7 in range(10, 0, -3), 10 in 1..10, 97 in b"abc"]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[1, 1, 1, 1, 0, 1, 0, 1]",
+					resValue.(*object.JList).ElementValues[2].String(),
+				)
+			},
+		},
+		{
+			name: "identity",
+			source: `
+				xs = [1]
+				ys = xs
+				m = {}
+				[ys is xs, [1] is xs, null is null, m["x"] is null, 1 is not null, "a" is "a", m is not m]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[1, 0, 1, 1, 1, 1, 0]",
+					resValue.(*object.JList).ElementValues[3].String(),
+				)
+			},
+		},
+		{
+			name: "identity of empty lists and closures",
+			source: `
+				same_empty = []
+				same_alias = same_empty
+				fun same_mk() -> fun() -> 1
+				same_fn = same_mk()
+				[[] is [], copy([]) is [], copy(same_empty) is same_empty, same_alias is same_empty, same_mk() is same_mk(), same_fn is same_fn]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[0, 0, 0, 1, 0, 1]", resValue.(*object.JList).ElementValues[4].String())
+			},
+		},
+		{
+			name: "membership of not container",
+			source: `
+				1 in 2
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "Illegal operation")
+			},
+		},
+		{
+			name: "null-safe operators",
+			source: `
+				cfg = {"db": {"port": 5432}}
+				none = null
+				[cfg.db.port, cfg?.db?["port"], cfg?.cache?.size, none?.a?["b"], cfg["host"] ?? "localhost", 0 ?? 1]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[5432, 5432, <null>, <null>, localhost, 0]",
+					resValue.(*object.JList).ElementValues[2].String(),
+				)
+			},
+		},
+		{
+			name: "null-safe access short-circuits the rest of the chain",
+			source: `
+				nothing = null
+				half = {"a": null}
+				[nothing?.a.b, nothing?["a"].b(1)[0:1], half?.a?.b, is_error(catch(fun() -> half?.a.b))]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[<null>, <null>, <null>, 1]",
+					resValue.(*object.JList).ElementValues[2].String(),
+				)
+			},
+		},
+		{
+			name: "null equality",
+			source: `
+				[1 == null, null == "a", null == null, [] != null, null in [1, null]]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[0, 0, 1, 1, 1]", resValue.(*object.JList).String())
+			},
+		},
+		{
+			name: "member access of null",
+			source: `
+				none = null
+				none.a
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "Illegal operation")
+			},
+		},
+		{
+			name: "pipe",
+			source: `
+				fun add(a, b) -> a + b
+				xs = 0..10
+					|> filter(fun(x) -> x > 6)
+					|> map(fun(x) -> add(x, 1))
+					|> to_list()
+				[1 |> add(2), xs, "abc" |> len]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[3, [8, 9, 10], 3]",
+					resValue.(*object.JList).ElementValues[2].String(),
+				)
+			},
+		},
+		{
+			name: "labeled loop",
+			source: `
+				found = null
+				outer: for i in 1..5 then
+					for j in 1..5 then
+						if j > i then continue outer
+						if i * j == 6 then
+							found = [i, j]
+							break outer
+						end
+					end
+				else
+					found = "none"
+				end
+				n = 0
+				while n < 3 then n = n + 1 else n = n * 10
+				[found, n]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[[3, 2], 30]", resValue.(*object.JList).ElementValues[4].String())
+			},
+		},
+		{
+			name: "undefined loop label",
+			source: `
+				for i in 0..3 then break outer
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "label 'outer' is not defined")
+			},
+		},
+		{
+			name: "enum",
+			source: `
+				enum Color red, green, blue end
+				enum HttpStatus
+					ok = 200
+					created
+				end
+				c = Color.green
+				[c, c.name, c.value, c == Color.green, c == Color.red, HttpStatus.created.value, [m.name for m in Color], HttpStatus.ok in Color]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[Color.green, green, 1, 1, 0, 201, [red, green, blue], 0]",
+					resValue.(*object.JList).ElementValues[3].String(),
+				)
+			},
+		},
+		{
+			name: "enum members as map keys",
+			source: `
+				enum KeyColor red, green end
+				color_names = {KeyColor.red: "r", KeyColor.green: "g"}
+				color_names[KeyColor.green] = "G"
+				[color_names[KeyColor.red], color_names, KeyColor.red in color_names, (KeyColor.red, 1) in {(KeyColor.red, 1): 0}, KeyColor.red is KeyColor.red]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[r, {KeyColor.red: r, KeyColor.green: G}, 1, 1, 1]",
+					resValue.(*object.JList).ElementValues[3].String(),
+				)
+			},
+		},
+		{
+			name: "enum member error points at its use",
+			source: `
+				enum PosColor red, green end
+				pos_first = PosColor.red
+				pos_first
+				PosColor.red + 1
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Equal(t, int64(4), errors.Cause(err).(*common.JRunTimeError).StartPos.Ln)
+			},
+		},
+		{
+			name: "trait",
+			source: `
+				trait Shape fun area(self) end
+				trait Sized
+					fun size(self)
+					fun scaled(self, k)
+				end
+				enum Square small, big end
+				impl Shape for Square
+					fun area(self) -> (self.value + 1) ^ 2
+				end
+				impl Sized for tuple
+					fun size(self) -> len(self)
+					fun scaled(self, k) -> [x * k for x in self]
+				end
+				[Square.big.area(), (1, 2, 3).size(), (1, 2).scaled(3), implements(Square.small, Shape), implements(1, Shape), implements((), Sized)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[4, 3, [3, 6], 1, 0, 1]", resValue.(*object.JList).ElementValues[5].String())
+			},
+		},
+		{
+			name: "impl of exec is scoped to its source",
+			source: `
+				exec_impl_len = exec("trait ExecSized fun exec_size(self) end\nimpl ExecSized for string\nfun exec_size(self) -> len(self)\nend\nn = 'ab'.exec_size()", {})
+				[catch(fun() -> "ab".exec_size()).kind, catch(fun() -> exec("'ab'.exec_size()")).kind]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[TypeError, TypeError]", resValue.(*object.JList).ElementValues[1].String())
+			},
+		},
+		{
+			name: "impl missing method",
+			source: `
+				trait Named
+					fun name_of(self)
+					fun rename(self, name)
+				end
+				impl Named for map
+					fun name_of(self) -> self.name
+				end
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Equal(t, common.TypeError, errors.Cause(err).(*common.JRunTimeError).GetKind())
+				require.Contains(t, err.Error(), "impl Named for map is missing method 'rename'")
+			},
+		},
+		{
+			name: "impl method args",
+			source: `
+				trait Labeled fun label(self) end
+				impl Labeled for number
+					fun label(self, prefix) -> prefix + self
+				end
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "method 'label' of Labeled must take 1 args, got 2")
+			},
+		},
+		{
+			name: "closure",
+			source: `
+				fun make_adder(adder_n) -> fun(x) -> x + adder_n
+				add5 = make_adder(5)
+				[add5(1), make_adder(2)(1)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[6, 3]", resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "decorators",
+			source: `
+				memo_calls = [0]
+				@memoize
+				fun memo_fib(n)
+					memo_calls[0] = memo_calls[0] + 1
+					if n < 2 then return n
+					return memo_fib(n - 1) + memo_fib(n - 2)
+				end
+				retry_calls = [0]
+				@retry(3)
+				fun retried()
+					retry_calls[0] = retry_calls[0] + 1
+					if retry_calls[0] < 3 then return [][0]
+					return "ok"
+				end
+				fun twice(twice_f) -> fun(x) -> twice_f(twice_f(x))
+				@twice
+				fun inc(x) -> x + 1
+				[memo_fib(30), memo_calls[0], retried(), retry_calls[0], inc(1), catch(retry(2)(fun() -> 1 / 0)).kind]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[832040, 31, ok, 3, 3, ZeroDivisionError]", resValue.(*object.JList).ElementValues[6].String())
+			},
+		},
+		{
+			name: "eval and exec",
+			source: `
+				eval_hidden = 1
+				eval_scope = {"n": 3}
+				exec("m = n * 10\nfun dbl(v) -> v * 2\nk = dbl(m)", eval_scope)
+				eval_err = catch(fun() -> exec("a = 1\nb = a / 0"))
+				eval_value = eval("x * 2 + len(xs)", {"x": 20, "xs": [1, 2]})
+				hidden_kind = catch(fun() -> eval("eval_hidden")).kind
+				[eval_value, eval_scope["k"], eval_scope["n"], hidden_kind, eval_err.kind, eval_err.line, catch(fun() -> eval("1 2")).kind]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[42, 60, 3, NameError, ZeroDivisionError, 2, SyntaxError]",
+					resValue.(*object.JList).ElementValues[6].String())
+			},
+		},
+		{
+			name:   "eval error position",
+			source: "eval(\"1 + a\", {})",
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "File <eval>, line 1, in <eval>")
+				require.Contains(t, err.Error(), "File <<eval>>, line 0, col 5\n\n1 + a\n    ^")
+			},
+		},
+		{
+			name: "macros",
+			source: `
+				macro mac_double_checked(name)
+					return quote
+						if unquote(name) < 0 then return error("negative " + unquote(name.name))
+						mac_res = unquote(name) * 2
+						mac_res
+					end
+				end
+				fun mac_double(n)
+					mac_double_checked(n)
+				end
+				mac_res = "kept"
+				macro mac_swap(a, b) -> ast_block([ast_assign("mac_tmp", a), ast_assign(a, b), ast_assign(b, ast_var("mac_tmp"))])
+				mac_x = 1
+				mac_y = 2
+				mac_swap(mac_x, mac_y)
+				mac_ast = quote(f(a, 1 + 2))
+				[mac_double(4), mac_double(-1).message, mac_res, [mac_x, mac_y], mac_ast.kind, mac_ast.name, len(mac_ast.args), type(mac_ast), mac_ast == quote(f(a, 1+2))]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[8, negative n, kept, [2, 1], call, f, 2, ast, 1]",
+					resValue.(*object.JList).ElementValues[8].String())
+			},
+		},
+		{
+			name: "macros of exec are scoped to its source",
+			source: `
+				exec("macro exec_mac(e) -> e\nexec_mac(1)")
+				[catch(fun() -> exec("exec_mac(2)")).kind, catch(fun() -> exec_mac(3)).kind]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[NameError, NameError]", resValue.(*object.JList).ElementValues[1].String())
+			},
+		},
+		{
+			name:   "macro error position",
+			source: "macro mac_bad(x)\n\treturn 1 / 0\nend\nmac_bad(1)",
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "File <test>, line 4, in <program>\n  File <test>, line 2, in mac_bad")
+			},
+		},
+		{
+			name: "docstrings",
+			source: `
+				## Adds two numbers.
+				## Returns their sum.
+				fun doc_add(a, b) -> a + b
+				fun doc_sq(x)
+					"Squares x."
+					x * x
+				end
+				## cached
+				@memoize
+				fun doc_memo(x) -> x
+				[doc_add, doc_sq, doc_memo, len]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+
+				elementValues := resValue.(*object.JList).ElementValues[3].(*object.JList).ElementValues
+				require.Equal(t, "Adds two numbers.\nReturns their sum.", elementValues[0].(*object.JFunction).Doc)
+				require.Equal(t, "Squares x.", elementValues[1].(*object.JFunction).Doc)
+				require.Equal(t, "cached", elementValues[2].(*object.JBuiltInFunction).Doc)
+				require.Equal(t, "Returns the number of characters of string or elements of bytes, list, tuple or map",
+					elementValues[3].(*object.JBuiltInFunction).Doc)
+			},
+		},
+		{
+			name: "reflection",
+			source: `
+				fun reflect_add(a, b)
+					return a + b
+				end
+				fun reflect_locals(p)
+					q = 2
+					if true then
+						let r = 3
+						return [locals(), dir()]
+					end
+				end
+				enum ReflectColor red, green end
+				[source(reflect_add), arity(reflect_add), params(reflect_add), callable(reflect_add), callable(1), type(ReflectColor.red), type(null), dir(ReflectColor), reflect_locals(1), has(globals(), "reflect_add"), has(globals(), "len")]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[fun reflect_add(a, b)\n\t\t\t\t\treturn a + b\n\t\t\t\tend, 2, [a, b], 1, 0, ReflectColor, null, [red, green], [{p: 1, q: 2, r: 3}, [p, q, r]], 1, 0]",
+					resValue.(*object.JList).ElementValues[3].String(),
+				)
+			},
+		},
+		{
+			name: "error value",
+			source: `
+				fun parse(s)
+					if s == "" then return error("empty input", {"code": 2})
+					return s
+				end
+				err = parse("")
+				[is_error(err), err.message, err.kind, err.code, err.line, err == error("empty input")]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[1, empty input, Error, 2, 3, 1]", resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "catch error kinds",
+			source: `
+				index_error = catch(fun() -> [1][5]).kind
+				zero_division_error = catch(fun() -> 1 / 0).kind
+				type_error = catch(fun() -> 1 + "a").kind
+				name_error = catch(fun() -> undefined_name).kind
+				stop_iteration = catch(fun() -> next(iter([]))).kind
+				io_error = catch(fun() -> read_bytes("/nonexistent")).kind
+				[index_error, zero_division_error, type_error, name_error, stop_iteration, io_error, catch(fun() -> 42)]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[IndexError, ZeroDivisionError, TypeError, NameError, StopIteration, IOError, 42]",
+					resValue.(*object.JList).ElementValues[6].String(),
+				)
+			},
+		},
+		{
+			name: "catch errors of built-in values and eval'd lexer",
+			source: `
+				cmd_key_error = catch(fun() -> run_cmd(["true"])[[1]])
+				merge_key_error = catch(fun() -> merge({}, {})[[1]])
+				illegal_char_error = catch(fun() -> eval("1 $ 2"))
+				expected_char_error = catch(fun() -> eval("'abc"))
+				[cmd_key_error.kind, merge_key_error.line, illegal_char_error, expected_char_error.kind]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[TypeError, 3, <SyntaxError: Illegal character '$'>, SyntaxError]",
+					resValue.(*object.JList).ElementValues[4].String(),
+				)
+			},
+		},
+		{
+			name: "block scope",
+			source: `
+				shadowed = 1
+				if true then
+					let shadowed = 2
+					from_block = shadowed
+				end
+				for index in 0..3 then last_index = index
+				fun counter()
+					n = 0
+					fun bump()
+						nonlocal n
+						global bump_calls
+						n = n + 1
+						bump_calls = n
+					end
+					bump()
+					bump()
+					return n
+				end
+				[shadowed, from_block, last_index, counter(), bump_calls]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[1, 2, 2, 2, 2]", resValue.(*object.JList).ElementValues[4].String())
+			},
+		},
+		{
+			name: "returned closure keeps its scope",
+			source: `
+				fun make_counter()
+					count = 0
+					fun next_count()
+						nonlocal count
+						count = count + 1
+						return count
+					end
+					return next_count
+				end
+				lexical_name = "program"
+				fun read_name() -> lexical_name
+				fun call_with_name()
+					lexical_name = "caller"
+					return read_name()
+				end
+				counter_a = make_counter()
+				counter_b = make_counter()
+				[counter_a(), counter_a(), counter_b(), call_with_name()]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[1, 2, 1, program]",
+					resValue.(*object.JList).ElementValues[len(resValue.(*object.JList).ElementValues)-1].String(),
+				)
+			},
+		},
+		{
+			name: "loop variable does not leak",
+			source: `
+				for loop_index in 0..3 then loop_index
+				loop_index
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "'loop_index' is not defined")
+			},
+		},
+		{
+			name: "const",
+			source: `
+				const limit = 10
+				limit = 11
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "cannot reassign const 'limit'")
+			},
+		},
+		{
+			name: "defer",
+			source: `
+				log = {}
+				fun f(x)
+					defer log["first"] = len(log)
+					defer log["second"] = len(log)
+					return x * 2
+				end
+				[f(2), log]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[4, {second: 0, first: 1}]", resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "defer with error",
+			source: `
+				log = {}
+				fun f()
+					defer log["done"] = 1
+					defer 1 + "a"
+					1 / 0
+				end
+				f()
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JRunTimeError{}, errors.Cause(err))
+				require.Contains(t, errors.Cause(err).Error(), "Division by zero")
+				require.Contains(t, err.Error(), "deferred expression failed too, TypeError: Illegal number type 'a'")
+			},
+		},
+		{
+			name: "defer sees let variables of its block",
+			source: `
+				defer_log = []
+				fun defer_in_block(flag)
+					if flag then
+						let handle = "file"
+						defer defer_log[0] = handle
+					end
+					return 1
+				end
+				defer_log = defer_log + 0
+				[defer_in_block(1), defer_log]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[1, [file]]", resValue.(*object.JList).ElementValues[3].String())
+			},
+		},
+		{
+			name: "defer outside function",
+			source: `
+				defer 1
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "defer is only allowed in function")
+			},
+		},
+		{
+			name: "unhashable key of built-in map",
+			source: `
+				merge({}, {})[[1]]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "Cannot hashed")
+				require.Contains(t, err.Error(), "line 1")
+			},
+		},
 		{
 			name: "shell",
 			source: `
@@ -149,6 +1151,92 @@ func TestRun(t *testing.T) {
 				require.Equal(t, "hello", resValue.(*object.JString).Value)
 			},
 		},
+		{
+			name: "shell interpolation",
+			source: `
+				shell_arg = "it's; echo no"
+				shell_args = ["a b", 1]
+				shell_flag = "-n"
+				[@"printf '%s|' {shell_arg} {shell_args}", @"echo {!shell_flag} {{x}}", run_cmd(["printf", "%s", shell_arg])["stdout"]]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[it's; echo no|a b|1|, {x}, it's; echo no]",
+					resValue.(*object.JList).ElementValues[3].String())
+			},
+		},
+		{
+			name: "shell literal keeps ${} and single-quoted text",
+			source: `
+				shell_home = run_shell("echo $HOME")["stdout"]
+				shell_file = "a b"
+				[@"echo ${HOME}" == shell_home, @"echo 'x {shell_file}' | awk '{print $2}'", @"printf %s {shell_file}"]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[1, {shell_file}\n, a b]", resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "shell interpolation inside double quotes",
+			source: `
+				shell_injected = "$(echo INJECTED) ` + "`echo too`" + ` \\ \""
+				shell_spaced = "a b"
+				[@"printf %s \"{shell_injected}\"", @"printf %s \"{shell_spaced}\""]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "[$(echo INJECTED) `echo too` \\ \", a b]",
+					resValue.(*object.JList).ElementValues[2].String())
+			},
+		},
+		{
+			name: "run_shell result",
+			source: `
+				proc_res = run_shell("cat; echo $PROC_ENV >&2; exit 3", {"stdin": "in", "env": {"PROC_ENV": 1}, "cwd": "/"})
+				proc_err = catch(fun() -> run_shell("exit 2", {"check": 1}))
+				proc_timeout = catch(fun() -> run_cmd(["sleep", "3"], {"timeout": 0.1}))
+				[proc_res["stdout"], proc_res["stderr"], proc_res["code"], proc_res["duration"] >= 0, proc_err, proc_timeout, @"echo warn >&2; echo ok"]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t,
+					"[in, 1\n, 3, 1, <IOError: exit status 2>, <IOError: Command timed out after 100ms>, ok\n]",
+					resValue.(*object.JList).ElementValues[3].String())
+			},
+		},
+		{
+			name: "run_shell timeout with background child",
+			source: `
+				[catch(fun() -> run_shell("sleep 3 & echo started", {"timeout": 0.2}))]
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, object.NewJList(nil), resValue)
+				require.Equal(t, "<IOError: Command timed out after 200ms>", resValue.(*object.JList).ElementValues[0].String())
+			},
+		},
+		{
+			name: "shell interpolation of map",
+			source: `
+				shell_map = {"a": 1}
+				@"echo {shell_map}"
+			`,
+			checkResult: func(t *testing.T, resValue interface{}, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "cannot interpolate map into shell command")
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -625,3 +1713,38 @@ func assignVariable(t *testing.T, variableAssign string) {
 	require.NoError(t, err)
 	require.NotNil(t, number)
 }
+
+// TestRunChecked isn't parallel, parallel tests run after CheckTypes is restored
+func TestRunChecked(t *testing.T) {
+	interpreter.CheckTypes = true
+	defer func() { interpreter.CheckTypes = false }()
+
+	resValue, err := interpreter.Run("<test>", `
+		fun checked_add(a: number, b: number) -> number
+			return a + b
+		end
+
+		checked_sum: number = checked_add(1, 2)
+	`)
+	require.NoError(t, err)
+	require.Equal(t, "3", resValue.(*object.JList).ElementValues[1].String())
+
+	_, err = interpreter.Run("<test>", `checked_add(1, "a")`)
+	require.Error(t, err)
+	require.Equal(t, common.TypeError, errors.Cause(err).(*common.JRunTimeError).GetKind())
+	require.Contains(t, err.Error(), "argument 'b' of checked_add must be number, got string")
+
+	_, err = interpreter.Run("<test>", `
+		fun checked_name() -> string
+			return 1
+		end
+
+		checked_name()
+	`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "return value of checked_name must be string, got number")
+
+	_, err = interpreter.Run("<test>", `checked_list: list = 1`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "variable 'checked_list' must be list, got number")
+}
@@ -0,0 +1,259 @@
+package interpreter
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/parser"
+	"github.com/IfanTsai/jirachi/token"
+)
+
+// maxMacroExpansionDepth limits macros whose expansion calls macros again
+const maxMacroExpansionDepth = 100
+
+// macroKey is key of macro in registry of program symbol table, so that macros are only visible to the program,
+// eval or exec source which defines them
+type macroKey struct {
+	name any
+}
+
+// gensymCount numbers variables renamed by quote, so that the renamed names are unique
+var gensymCount atomic.Int64
+
+// expandMacros defines macros of ast and replaces calls of defined macros by the ast returned by the macro,
+// which is called with asts of its args before the program runs. Macros must be defined before they are called
+func expandMacros(ast parser.JNode, context *common.JContext) (parser.JNode, error) {
+	return expand(ast, context, 0)
+}
+
+func expand(ast parser.JNode, context *common.JContext, depth int) (parser.JNode, error) {
+	var err error
+
+	resNode := parser.Rewrite(ast, func(node parser.JNode) (parser.JNode, bool) {
+		if err != nil {
+			return node, false
+		}
+
+		switch node := node.(type) {
+		case *parser.JMacroDefNode:
+			err = defineMacro(node, context)
+
+			return node, false
+		case *parser.JQuoteNode:
+			// macros in quoted node are expanded after the ast is returned by macro
+			return node, false
+		case *parser.JCallExprNode:
+			macro, ok := getMacro(node, context)
+			if !ok {
+				return node, true
+			}
+
+			var expandedNode parser.JNode
+			expandedNode, err = callMacro(macro, node, context, depth)
+
+			return expandedNode, false
+		}
+
+		return node, true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resNode, nil
+}
+
+// defineMacro creates macro function, which only sees built-in values because the program hasn't run yet
+func defineMacro(node *parser.JMacroDefNode, context *common.JContext) error {
+	bodyNode, err := expand(node.FuncDefNode.BodyNode, context, 0)
+	if err != nil {
+		return err
+	}
+
+	funcDefNode := *node.FuncDefNode
+	funcDefNode.BodyNode = bodyNode
+
+	function, err := NewJInterpreter(context).newFunction(&funcDefNode)
+	if err != nil {
+		return err
+	}
+
+	function.Closure = newBuiltInSymbolTable()
+	context.SymbolTable.Global().Registry.Set(macroKey{name: node.Token.Value}, function)
+
+	return nil
+}
+
+func getMacro(node *parser.JCallExprNode, context *common.JContext) (*object.JFunction, bool) {
+	varAccessNode, ok := node.CallNode.(*parser.JVarAccessNode)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := context.SymbolTable.Global().Registry.Get(macroKey{name: varAccessNode.Token.Value})
+	if !ok {
+		return nil, false
+	}
+
+	return macro.(*object.JFunction), true
+}
+
+// callMacro calls macro with asts of args of node and expands the returned ast again,
+// errors of the macro are reported with position of node as the caller
+func callMacro(
+	macro *object.JFunction,
+	node *parser.JCallExprNode,
+	context *common.JContext,
+	depth int,
+) (parser.JNode, error) {
+	if depth >= maxMacroExpansionDepth {
+		return nil, createMacroError(macro, node, context, common.RuntimeError,
+			fmt.Sprintf("expansion of macro %v is nested too deeply", macro.Value))
+	}
+
+	argValues := make([]object.JValue, len(node.ArgNodes))
+	for index, argNode := range node.ArgNodes {
+		argValues[index] = object.NewJAst(argNode).SetJPos(argNode.GetStartPos(), argNode.GetEndPos()).
+			SetJContext(context)
+	}
+
+	function := macro.Copy().SetJPos(node.StartPos, node.EndPos).SetJContext(context).(*object.JFunction)
+
+	resValue, err := executeFunction(function, argValues)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to expand macro %v", macro.Value))
+	}
+
+	resNode, ok := valueToNode(resValue, node.StartPos, node.EndPos)
+	if !ok {
+		return nil, createMacroError(macro, node, context, common.TypeError,
+			fmt.Sprintf("macro %v must return ast, number, string, list or null, got %s",
+				macro.Value, getTypeName(resValue)))
+	}
+
+	return expand(resNode, context, depth+1)
+}
+
+// createMacroError creates error pointing to definition of macro, which is called at node
+func createMacroError(macro *object.JFunction, node parser.JNode, context *common.JContext, kind, details string) error {
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: macro.SourceStartPos,
+			EndPos:   macro.SourceEndPos,
+		},
+		Context: common.NewJContext(macro.Value.(string), nil, context, node.GetStartPos()),
+		Kind:    kind,
+		Details: details,
+	}, "failed to expand macro")
+}
+
+// visitQuoteNode returns ast of the quoted node in which unquote expressions are replaced by asts of their values.
+// Variables bound in the quoted node are renamed to unique names, so that they never capture variables
+// of the code which the ast is inserted into
+func (i *JInterpreter) visitQuoteNode(node *parser.JQuoteNode) (object.JValue, error) {
+	names := make(map[string]string)
+	for _, name := range parser.BoundNames(node.Node) {
+		if _, ok := names[name]; !ok {
+			names[name] = fmt.Sprintf("%s@%d", name, gensymCount.Add(1))
+		}
+	}
+
+	var err error
+
+	quotedNode := parser.Rewrite(parser.RenameVars(node.Node, names), func(node parser.JNode) (parser.JNode, bool) {
+		unquoteNode, ok := node.(*parser.JUnquoteNode)
+		if !ok || err != nil {
+			// unquote expressions of nested quote belong to the nested quote
+			return node, !ok && node.Type() != parser.Quote
+		}
+
+		var value object.JValue
+		if value, err = i.visit(unquoteNode.Node); err != nil {
+			return node, false
+		}
+
+		valueNode, ok := valueToNode(value, unquoteNode.StartPos, unquoteNode.EndPos)
+		if !ok {
+			err = errors.Wrap(&common.JRunTimeError{
+				JError: &common.JError{
+					StartPos: unquoteNode.StartPos,
+					EndPos:   unquoteNode.EndPos,
+				},
+				Context: i.Context,
+				Kind:    common.TypeError,
+				Details: fmt.Sprintf("cannot unquote %s, expected ast, number, string, list or null", getTypeName(value)),
+			}, "failed to visit quote node")
+		}
+
+		return valueNode, false
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewJAst(quotedNode).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+}
+
+// visitMacroDefNode does nothing, because macros are defined while expanding macros
+func (i *JInterpreter) visitMacroDefNode(node *parser.JMacroDefNode) (object.JValue, error) {
+	return object.NewJNull().SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context), nil
+}
+
+// valueToNode converts value to node between startPos and endPos, asts are converted to their nodes
+// and numbers, strings, lists and null to literals
+func valueToNode(value object.JValue, startPos, endPos *common.JPosition) (parser.JNode, bool) {
+	newBaseNode := func(tokenType token.JTokenType, tokenValue any) *parser.JBaseNode {
+		return &parser.JBaseNode{
+			Token:    token.NewJToken(tokenType, tokenValue, startPos, endPos),
+			StartPos: startPos,
+			EndPos:   endPos,
+		}
+	}
+
+	if value == nil {
+		return nil, false
+	}
+
+	if object.IsNull(value) {
+		return &parser.JVarAccessNode{JBaseNode: newBaseNode(token.IDENTIFIER, "null")}, true
+	}
+
+	switch value := value.(type) {
+	case *object.JAst:
+		return value.Node, true
+	case *object.JNumber:
+		if _, ok := value.Value.(int); ok {
+			return &parser.JNumberNode{JBaseNode: newBaseNode(token.INT, value.Value)}, true
+		}
+
+		return &parser.JNumberNode{JBaseNode: newBaseNode(token.FLOAT, value.Value)}, true
+	case *object.JString:
+		return &parser.JStringNode{JBaseNode: newBaseNode(token.STRING, value.Value)}, true
+	case *object.JList:
+		elementNodes := make([]parser.JNode, len(value.ElementValues))
+		for index, elementValue := range value.ElementValues {
+			elementNode, ok := valueToNode(elementValue, startPos, endPos)
+			if !ok {
+				return nil, false
+			}
+
+			elementNodes[index] = elementNode
+		}
+
+		return &parser.JListNode{
+			JBaseNode: &parser.JBaseNode{
+				StartPos: startPos,
+				EndPos:   endPos,
+			},
+			ElementNodes: elementNodes,
+		}, true
+	}
+
+	return nil, false
+}
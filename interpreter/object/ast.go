@@ -0,0 +1,125 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/parser"
+	"github.com/IfanTsai/jirachi/token"
+)
+
+// JAst is unevaluated syntax tree created by quote or passed to macros, JBaseValue.Value is the node
+type JAst struct {
+	*JBaseValue
+	Node parser.JNode
+}
+
+func NewJAst(node parser.JNode) *JAst {
+	return &JAst{
+		JBaseValue: &JBaseValue{
+			Value: node,
+		},
+		Node: node,
+	}
+}
+
+func (a *JAst) SetJPos(startPos, endPos *common.JPosition) JValue {
+	a.StartPos = startPos
+	a.EndPos = endPos
+
+	return a
+}
+
+func (a *JAst) SetJContext(context *common.JContext) JValue {
+	a.Context = context
+
+	return a
+}
+
+// Copy returns a new value sharing the node, nodes are never modified
+func (a *JAst) Copy() JValue {
+	return NewJAst(a.Node).SetJPos(a.StartPos, a.EndPos).SetJContext(a.Context)
+}
+
+func (a *JAst) String() string {
+	return fmt.Sprintf("<ast %v>", a.Node)
+}
+
+func (a *JAst) IsTrue() bool {
+	return true
+}
+
+// EqualTo reports whether two asts have the same structure, positions of nodes are ignored
+func (a *JAst) EqualTo(other JValue) (JValue, error) {
+	otherAst, ok := other.(*JAst)
+
+	return NewJNumber(boolToNumber(ok && a.Node.String() == otherAst.Node.String())).SetJContext(a.Context), nil
+}
+
+func (a *JAst) NotEqualTo(other JValue) (JValue, error) {
+	otherAst, ok := other.(*JAst)
+
+	return NewJNumber(boolToNumber(!ok || a.Node.String() != otherAst.Node.String())).SetJContext(a.Context), nil
+}
+
+// IndexAccess returns field of node, eg. node.kind, node.name, node.value, node.args or node.children
+func (a *JAst) IndexAccess(arg JValue) (JValue, error) {
+	switch arg.GetValue() {
+	case "kind":
+		return NewJString(a.Node.Type().String()).SetJContext(a.Context), nil
+	case "name":
+		return a.name(), nil
+	case "value":
+		switch a.Node.(type) {
+		case *parser.JNumberNode:
+			return NewJNumber(a.Node.GetToken().Value).SetJContext(a.Context), nil
+		case *parser.JStringNode:
+			return NewJString(a.Node.GetToken().Value).SetJContext(a.Context), nil
+		}
+
+		return NewJNull().SetJContext(a.Context), nil
+	case "args":
+		if callNode, ok := a.Node.(*parser.JCallExprNode); ok {
+			return a.newList(callNode.ArgNodes), nil
+		}
+
+		return NewJList(nil).SetJContext(a.Context), nil
+	case "children":
+		return a.newList(parser.Children(a.Node)), nil
+	}
+
+	return nil, errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: arg.GetStartPos(),
+			EndPos:   arg.GetEndPos(),
+		},
+		Context: a.Context,
+		Kind:    common.NameError,
+		Details: fmt.Sprintf("ast has no field '%s', expected kind, name, value, args or children", arg),
+	}, "failed to index")
+}
+
+// name returns name of variable, function or called variable of node, null if node has no name
+func (a *JAst) name() JValue {
+	node := a.Node
+	if callNode, ok := node.(*parser.JCallExprNode); ok {
+		node = callNode.CallNode
+	}
+
+	if nameToken := node.GetToken(); nameToken != nil && nameToken.Type == token.IDENTIFIER {
+		return NewJString(nameToken.Value).SetJContext(a.Context)
+	}
+
+	return NewJNull().SetJContext(a.Context)
+}
+
+func (a *JAst) newList(nodes []parser.JNode) JValue {
+	elementValues := make([]JValue, len(nodes))
+	for index, node := range nodes {
+		elementValues[index] = NewJAst(node).SetJPos(node.GetStartPos(), node.GetEndPos()).SetJContext(a.Context)
+	}
+
+	return NewJList(elementValues).SetJContext(a.Context)
+}
@@ -1,12 +1,19 @@
 package object
 
-import "github.com/IfanTsai/jirachi/common"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+)
 
 type ExecuteFunc func(function *JBuiltInFunction, args []JValue) (JValue, error)
 
 type JBuiltInFunction struct {
 	*JFunction
-	ExecuteCb ExecuteFunc
+	ExecuteCb        ExecuteFunc
+	OptionalArgCount int // the last OptionalArgCount args can be omitted
 }
 
 func NewJBuiltInFunction(funcName interface{}, argNames []string, executeFunc ExecuteFunc) *JBuiltInFunction {
@@ -34,8 +41,50 @@ func (bif *JBuiltInFunction) SetJContext(context *common.JContext) JValue {
 	return bif
 }
 
+// SetOptionalArgCount makes the last count args can be omitted, the execute callback checks the length of args
+func (bif *JBuiltInFunction) SetOptionalArgCount(count int) *JBuiltInFunction {
+	bif.OptionalArgCount = count
+
+	return bif
+}
+
+// SetDoc sets documentation shown by help
+func (bif *JBuiltInFunction) SetDoc(doc string) *JBuiltInFunction {
+	bif.Doc = doc
+
+	return bif
+}
+
 func (bif *JBuiltInFunction) Copy() JValue {
-	return NewJBuiltInFunction(bif.Value, bif.ArgNames, bif.ExecuteCb)
+	return NewJBuiltInFunction(bif.Value, bif.ArgNames, bif.ExecuteCb).
+		SetOptionalArgCount(bif.OptionalArgCount).
+		SetDoc(bif.Doc)
+}
+
+func (bif *JBuiltInFunction) CheckArgs(argValues []JValue) error {
+	if bif.OptionalArgCount == 0 {
+		return bif.JFunction.CheckArgs(argValues)
+	}
+
+	minArgCount := len(bif.ArgNames) - bif.OptionalArgCount
+	if len(argValues) >= minArgCount && len(argValues) <= len(bif.ArgNames) {
+		return nil
+	}
+
+	details := fmt.Sprintf("%d too many args passed into %v", len(argValues)-len(bif.ArgNames), bif.GetValue())
+	if len(argValues) < minArgCount {
+		details = fmt.Sprintf("%d too few passed into %v", minArgCount-len(argValues), bif.GetValue())
+	}
+
+	return errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: bif.GetStartPos(),
+			EndPos:   bif.GetEndPos(),
+		},
+		Context: bif.GetContext(),
+		Kind:    common.TypeError,
+		Details: details,
+	}, "failed to execute")
 }
 
 func (bif *JBuiltInFunction) Execute(args []JValue) (JValue, error) {
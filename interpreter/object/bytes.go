@@ -0,0 +1,209 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+)
+
+type JBytes struct {
+	*JBaseValue
+}
+
+func NewJBytes(value []byte) *JBytes {
+	return &JBytes{
+		JBaseValue: &JBaseValue{
+			Value: value,
+		},
+	}
+}
+
+func (b *JBytes) SetJPos(startPos, endPos *common.JPosition) JValue {
+	b.StartPos = startPos
+	b.EndPos = endPos
+
+	return b
+}
+
+func (b *JBytes) SetJContext(context *common.JContext) JValue {
+	b.Context = context
+
+	return b
+}
+
+// Copy shares the underlying bytes, bytes is immutable
+func (b *JBytes) Copy() JValue {
+	return NewJBytes(b.Value.([]byte))
+}
+
+// String returns printable representation, eg. b"ab\x00"
+func (b *JBytes) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString("b\"")
+	for _, char := range b.Value.([]byte) {
+		switch {
+		case char == '"' || char == '\\':
+			strBuilder.WriteByte('\\')
+			strBuilder.WriteByte(char)
+		case char == '\n':
+			strBuilder.WriteString("\\n")
+		case char == '\t':
+			strBuilder.WriteString("\\t")
+		case char < ' ' || char > '~':
+			strBuilder.WriteString(fmt.Sprintf("\\x%02x", char))
+		default:
+			strBuilder.WriteByte(char)
+		}
+	}
+	strBuilder.WriteByte('"')
+
+	return strBuilder.String()
+}
+
+func (b *JBytes) AddTo(other JValue) (JValue, error) {
+	otherBytes, ok := other.(*JBytes)
+	if !ok {
+		return nil, b.createIllegalOperationError(other, "add")
+	}
+
+	value := b.Value.([]byte)
+	resValue := make([]byte, 0, len(value)+len(otherBytes.Value.([]byte)))
+	resValue = append(resValue, value...)
+	resValue = append(resValue, otherBytes.Value.([]byte)...)
+
+	return NewJBytes(resValue).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) MulBy(other JValue) (JValue, error) {
+	count, ok := other.GetValue().(int)
+	if !ok || count < 0 {
+		return nil, b.createIllegalOperationError(other, "mul")
+	}
+
+	return NewJBytes(bytes.Repeat(b.Value.([]byte), count)).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) EqualTo(other JValue) (JValue, error) {
+	res, err := b.compare(other, "compare equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res == 0)).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) NotEqualTo(other JValue) (JValue, error) {
+	res, err := b.compare(other, "compare not equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res != 0)).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) LessThan(other JValue) (JValue, error) {
+	res, err := b.compare(other, "compare less than")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res < 0)).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) LessThanOrEqualTo(other JValue) (JValue, error) {
+	res, err := b.compare(other, "compare less than or equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res <= 0)).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) GreaterThan(other JValue) (JValue, error) {
+	res, err := b.compare(other, "compare greater than")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res > 0)).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) GreaterThanOrEqualTo(other JValue) (JValue, error) {
+	res, err := b.compare(other, "compare greater than or equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res >= 0)).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) IsTrue() bool {
+	return len(b.Value.([]byte)) > 0
+}
+
+func (b *JBytes) Not() (JValue, error) {
+	return NewJNumber(boolToNumber(!b.IsTrue())).SetJContext(b.Context), nil
+}
+
+// IndexAccess returns the byte at index as integer number
+func (b *JBytes) IndexAccess(arg JValue) (JValue, error) {
+	index, ok := arg.GetValue().(int)
+	if !ok {
+		return nil, createNumberTypeError(arg, "index")
+	}
+
+	value := b.Value.([]byte)
+	if index < 0 || index >= len(value) {
+		return nil, errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: arg.GetStartPos(),
+				EndPos:   arg.GetEndPos(),
+			},
+			Context: b.Context,
+			Kind:    common.IndexError,
+			Details: "index integer number must >= 0 and < length of bytes",
+		}, "failed to index")
+	}
+
+	return NewJNumber(int(value[index])).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) SliceAccess(startArg, endArg JValue) (JValue, error) {
+	value := b.Value.([]byte)
+
+	start, end, err := getSliceRange(startArg, endArg, len(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJBytes(value[start:end]).SetJContext(b.Context), nil
+}
+
+func (b *JBytes) compare(other JValue, operation string) (int, error) {
+	otherBytes, ok := other.(*JBytes)
+	if !ok {
+		return 0, b.createIllegalOperationError(other, operation)
+	}
+
+	return bytes.Compare(b.Value.([]byte), otherBytes.Value.([]byte)), nil
+}
+
+// Contains reports whether other is a sub-bytes or an integer number byte of bytes
+func (b *JBytes) Contains(other JValue) (JValue, error) {
+	var res bool
+
+	switch otherValue := other.GetValue().(type) {
+	case []byte:
+		res = bytes.Contains(b.Value.([]byte), otherValue)
+	case int:
+		res = otherValue >= 0 && otherValue <= 0xff && bytes.IndexByte(b.Value.([]byte), byte(otherValue)) >= 0
+	default:
+		return nil, b.createIllegalOperationError(b, "contains")
+	}
+
+	return NewJNumber(boolToNumber(res)).SetJContext(b.Context), nil
+}
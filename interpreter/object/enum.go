@@ -3,8 +3,20 @@ package object
 const (
 	Number          = "number"
 	String          = "string"
+	Bytes           = "bytes"
 	List            = "list"
+	Tuple           = "tuple"
+	Map             = "map"
+	Range           = "range"
+	Iterator        = "iterator"
+	Error           = "error"
+	Enum            = "enum"
+	EnumMember      = "enum member"
+	Trait           = "trait"
+	Ast             = "ast"
 	Function        = "function"
 	BuiltInFunction = "built-in function"
+	Null            = "null"
+	Any             = "any" // only used by type annotation
 	Unknow          = "Unknow"
 )
@@ -0,0 +1,184 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+)
+
+// JEnum is enum type declared by enum statement, JBaseValue.Value is the enum name
+type JEnum struct {
+	*JBaseValue
+	Members []*JEnumMember // in declaration order
+}
+
+// JEnumMember is member of enum, JBaseValue.Value is the member name.
+// Members are only equal to the same member of the same enum, even if they have the same value
+type JEnumMember struct {
+	*JBaseValue
+	Enum        *JEnum
+	MemberValue JValue
+}
+
+func NewJEnum(name string) *JEnum {
+	return &JEnum{
+		JBaseValue: &JBaseValue{
+			Value: name,
+		},
+	}
+}
+
+// AddMember appends member of name with value to enum
+func (e *JEnum) AddMember(name string, value JValue) *JEnumMember {
+	member := &JEnumMember{
+		JBaseValue: &JBaseValue{
+			Value:   name,
+			Context: e.Context,
+		},
+		Enum:        e,
+		MemberValue: value,
+	}
+
+	e.Members = append(e.Members, member)
+
+	return member
+}
+
+func (e *JEnum) SetJPos(startPos, endPos *common.JPosition) JValue {
+	e.StartPos = startPos
+	e.EndPos = endPos
+
+	return e
+}
+
+func (e *JEnum) SetJContext(context *common.JContext) JValue {
+	e.Context = context
+
+	return e
+}
+
+// Copy returns the enum itself, enum cannot be modified
+func (e *JEnum) Copy() JValue {
+	return e
+}
+
+func (e *JEnum) String() string {
+	return fmt.Sprintf("<enum %v>", e.Value)
+}
+
+func (e *JEnum) IsTrue() bool {
+	return true
+}
+
+// IndexAccess returns member by name, eg. Color.red or Color["red"], positioned at name
+func (e *JEnum) IndexAccess(arg JValue) (JValue, error) {
+	name, ok := arg.GetValue().(string)
+	if ok {
+		for _, member := range e.Members {
+			if member.Value == name {
+				return member.Copy().SetJPos(arg.GetStartPos(), arg.GetEndPos()), nil
+			}
+		}
+	}
+
+	return nil, errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: arg.GetStartPos(),
+			EndPos:   arg.GetEndPos(),
+		},
+		Context: e.Context,
+		Kind:    common.NameError,
+		Details: fmt.Sprintf("'%s' is not a member of enum %v", arg, e.Value),
+	}, "failed to index")
+}
+
+// Contains reports whether other is a member of enum
+func (e *JEnum) Contains(other JValue) (JValue, error) {
+	member, ok := other.(*JEnumMember)
+
+	return NewJNumber(boolToNumber(ok && member.Enum == e)).SetJContext(e.Context), nil
+}
+
+func (e *JEnum) Iter() *JIterator {
+	members := make([]JValue, len(e.Members))
+	for index, member := range e.Members {
+		members[index] = member.Copy()
+	}
+
+	return newSliceIterator(func() []JValue {
+		return members
+	})
+}
+
+func (m *JEnumMember) SetJPos(startPos, endPos *common.JPosition) JValue {
+	m.StartPos = startPos
+	m.EndPos = endPos
+
+	return m
+}
+
+func (m *JEnumMember) SetJContext(context *common.JContext) JValue {
+	m.Context = context
+
+	return m
+}
+
+// Copy returns a new reference to the member, which can be positioned where it is accessed
+// and is still equal to the member
+func (m *JEnumMember) Copy() JValue {
+	return &JEnumMember{
+		JBaseValue: &JBaseValue{
+			Value:    m.Value,
+			StartPos: m.StartPos,
+			EndPos:   m.EndPos,
+			Context:  m.Context,
+		},
+		Enum:        m.Enum,
+		MemberValue: m.MemberValue,
+	}
+}
+
+// isSameMember reports whether other refers to the same member of the same enum as m
+func (m *JEnumMember) isSameMember(other JValue) bool {
+	otherMember, ok := other.(*JEnumMember)
+
+	return ok && m.Enum == otherMember.Enum && m.Value == otherMember.Value
+}
+
+func (m *JEnumMember) String() string {
+	return fmt.Sprintf("%v.%v", m.Enum.Value, m.Value)
+}
+
+func (m *JEnumMember) IsTrue() bool {
+	return true
+}
+
+// IndexAccess returns field name or value of member, eg. Color.red.value
+func (m *JEnumMember) IndexAccess(arg JValue) (JValue, error) {
+	switch arg.GetValue() {
+	case "name":
+		return NewJString(m.Value).SetJContext(m.Context), nil
+	case "value":
+		return m.MemberValue, nil
+	}
+
+	return nil, errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: arg.GetStartPos(),
+			EndPos:   arg.GetEndPos(),
+		},
+		Context: m.Context,
+		Kind:    common.NameError,
+		Details: fmt.Sprintf("enum member has no field '%s', expected name or value", arg),
+	}, "failed to index")
+}
+
+func (m *JEnumMember) EqualTo(other JValue) (JValue, error) {
+	return NewJNumber(boolToNumber(m.isSameMember(other))).SetJContext(m.Context), nil
+}
+
+func (m *JEnumMember) NotEqualTo(other JValue) (JValue, error) {
+	return NewJNumber(boolToNumber(!m.isSameMember(other))).SetJContext(m.Context), nil
+}
@@ -0,0 +1,142 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+)
+
+// JErrorValue is error as value of script, JBaseValue.Value is the message
+type JErrorValue struct {
+	*JBaseValue
+	Kind      string
+	Data      JValue // map of extra fields or null
+	Line      int
+	Traceback string
+}
+
+func NewJErrorValue(kind, message string, data JValue, line int, traceback string) *JErrorValue {
+	return &JErrorValue{
+		JBaseValue: &JBaseValue{
+			Value: message,
+		},
+		Kind:      kind,
+		Data:      data,
+		Line:      line,
+		Traceback: traceback,
+	}
+}
+
+// MakeJErrorValue converts error raised by interpreter to error value, line is 0 and traceback is empty
+// if position of the error is unknown
+func MakeJErrorValue(err error) *JErrorValue {
+	switch cause := errors.Cause(err).(type) {
+	case *common.JRunTimeError:
+		traceback := ""
+		if cause.StartPos != nil {
+			traceback = cause.Traceback()
+		}
+
+		return NewJErrorValue(cause.GetKind(), cause.Details, NewJNull(), errorLine(cause.JError), traceback)
+	case *common.JNumberTypeError:
+		return NewJErrorValue(common.TypeError, fmt.Sprintf("Illegal number type '%v'", cause.Number),
+			NewJNull(), errorLine(cause.JError), "")
+	case *common.JInvalidSyntaxError:
+		return NewJErrorValue(common.SyntaxError, cause.Details, NewJNull(), errorLine(cause.JError), "")
+	case *common.JIllegalCharacterError:
+		return NewJErrorValue(common.SyntaxError, fmt.Sprintf("Illegal character '%c'", cause.IllegalChar),
+			NewJNull(), errorLine(cause.JError), "")
+	case *common.JExpectedCharacterError:
+		return NewJErrorValue(common.SyntaxError, fmt.Sprintf("Expected character '%c'", cause.ExpectedChar),
+			NewJNull(), errorLine(cause.JError), "")
+	}
+
+	return NewJErrorValue(common.RuntimeError, errors.Cause(err).Error(), NewJNull(), 0, "")
+}
+
+// errorLine returns line of error starting from 1, or 0 if its position is unknown
+func errorLine(err *common.JError) int {
+	if err == nil || err.StartPos == nil {
+		return 0
+	}
+
+	return int(err.StartPos.Ln + 1)
+}
+
+func (e *JErrorValue) SetJPos(startPos, endPos *common.JPosition) JValue {
+	e.StartPos = startPos
+	e.EndPos = endPos
+
+	return e
+}
+
+func (e *JErrorValue) SetJContext(context *common.JContext) JValue {
+	e.Context = context
+
+	return e
+}
+
+// Copy returns the error itself, error value is immutable
+func (e *JErrorValue) Copy() JValue {
+	return e
+}
+
+func (e *JErrorValue) String() string {
+	return fmt.Sprintf("<%s: %v>", e.Kind, e.Value)
+}
+
+func (e *JErrorValue) IsTrue() bool {
+	return true
+}
+
+// IndexAccess returns field message, kind, line, traceback or data of error,
+// other names are looked up in data, eg. e.code is e.data["code"]
+func (e *JErrorValue) IndexAccess(arg JValue) (JValue, error) {
+	name, ok := arg.GetValue().(string)
+	if !ok {
+		return nil, e.createIllegalOperationError(arg, "index")
+	}
+
+	var resValue JValue
+
+	switch name {
+	case "message":
+		resValue = NewJString(e.Value)
+	case "kind":
+		resValue = NewJString(e.Kind)
+	case "line":
+		resValue = NewJNumber(e.Line)
+	case "traceback":
+		resValue = NewJString(e.Traceback)
+	case "data":
+		return e.Data, nil
+	default:
+		if _, ok := e.Data.(*JMap); !ok {
+			return NewJNull().SetJContext(e.Context), nil
+		}
+
+		return e.Data.IndexAccess(arg)
+	}
+
+	return resValue.SetJContext(e.Context), nil
+}
+
+// EqualTo reports whether other is error of the same kind and message, data isn't compared
+// because maps cannot be compared
+func (e *JErrorValue) EqualTo(other JValue) (JValue, error) {
+	otherError, ok := other.(*JErrorValue)
+	res := ok && e.Kind == otherError.Kind && e.Value == otherError.Value
+
+	return NewJNumber(boolToNumber(res)).SetJContext(e.Context), nil
+}
+
+func (e *JErrorValue) NotEqualTo(other JValue) (JValue, error) {
+	res, err := e.EqualTo(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Not()
+}
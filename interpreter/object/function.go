@@ -10,8 +10,15 @@ import (
 
 type JFunction struct {
 	*JBaseValue
-	ArgNames []string
-	BodyNode parser.JNode
+	ArgNames   []string
+	ArgTypes   []string // type annotations of args, empty string if the arg isn't annotated
+	ReturnType string   // empty string if the return value isn't annotated
+	BodyNode   parser.JNode
+	Closure    *common.JSymbolTable // symbol table of scope which function is defined in, may be nil
+	// position of function definition, StartPos and EndPos are changed to where function is accessed
+	SourceStartPos *common.JPosition
+	SourceEndPos   *common.JPosition
+	Doc            string // docstring of function, see help
 }
 
 func NewJFunction(funcName interface{}, argNames []string, bodyNode parser.JNode) *JFunction {
@@ -41,8 +48,30 @@ func (f *JFunction) SetJContext(context *common.JContext) JValue {
 	return f
 }
 
+// SetTypes sets type annotations of args and return value
+func (f *JFunction) SetTypes(argTypes []string, returnType string) *JFunction {
+	f.ArgTypes = argTypes
+	f.ReturnType = returnType
+
+	return f
+}
+
 func (f *JFunction) Copy() JValue {
-	return NewJFunction(f.Value, f.ArgNames, f.BodyNode)
+	function := NewJFunction(f.Value, f.ArgNames, f.BodyNode).SetTypes(f.ArgTypes, f.ReturnType)
+	function.Closure = f.Closure
+	function.SourceStartPos, function.SourceEndPos = f.SourceStartPos, f.SourceEndPos
+	function.Doc = f.Doc
+
+	return function
+}
+
+// Source returns text of function definition, empty string if its position is unknown
+func (f *JFunction) Source() string {
+	if f.SourceStartPos == nil || f.SourceEndPos == nil {
+		return ""
+	}
+
+	return f.SourceStartPos.Text[f.SourceStartPos.Index:f.SourceEndPos.Index]
 }
 
 func (f *JFunction) String() string {
@@ -57,6 +86,7 @@ func (f *JFunction) CheckArgs(argValues []JValue) error {
 				EndPos:   f.GetEndPos(),
 			},
 			Context: f.GetContext(),
+			Kind:    common.TypeError,
 			Details: fmt.Sprintf("%d too many args passed into %v", len(argValues)-len(f.ArgNames), f.GetValue()),
 		}, "failed to execute")
 	}
@@ -68,6 +98,7 @@ func (f *JFunction) CheckArgs(argValues []JValue) error {
 				EndPos:   f.GetEndPos(),
 			},
 			Context: f.GetContext(),
+			Kind:    common.TypeError,
 			Details: fmt.Sprintf("%d too few passed into %v", len(f.ArgNames)-len(argValues), f.GetValue()),
 		}, "failed to execute")
 	}
@@ -0,0 +1,177 @@
+package object
+
+import (
+	"github.com/IfanTsai/jirachi/common"
+)
+
+// NextFunc returns the next value of iterator, ok is false when the iterator is exhausted
+type NextFunc func() (value JValue, ok bool, err error)
+
+// JIterable is implemented by values which can be consumed by for ... in and built-in functions
+type JIterable interface {
+	JValue
+	Iter() *JIterator
+}
+
+// JIterator is a lazy stream of values, iterating it consumes the values
+type JIterator struct {
+	*JBaseValue
+	NextCb NextFunc
+}
+
+func NewJIterator(nextFunc NextFunc) *JIterator {
+	return &JIterator{
+		JBaseValue: &JBaseValue{},
+		NextCb:     nextFunc,
+	}
+}
+
+func (it *JIterator) SetJPos(startPos, endPos *common.JPosition) JValue {
+	it.StartPos = startPos
+	it.EndPos = endPos
+
+	return it
+}
+
+func (it *JIterator) SetJContext(context *common.JContext) JValue {
+	it.Context = context
+
+	return it
+}
+
+// Copy returns the iterator itself, the iterating state is shared by all references
+func (it *JIterator) Copy() JValue {
+	return it
+}
+
+func (it *JIterator) String() string {
+	return "<iterator>"
+}
+
+func (it *JIterator) IsTrue() bool {
+	return true
+}
+
+func (it *JIterator) Iter() *JIterator {
+	return it
+}
+
+func (it *JIterator) Next() (JValue, bool, error) {
+	return it.NextCb()
+}
+
+// Contains consumes the iterator until a value equal to other is found
+func (it *JIterator) Contains(other JValue) (JValue, error) {
+	for {
+		value, ok, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok || Equals(value, other) {
+			return NewJNumber(boolToNumber(ok)).SetJContext(it.Context), nil
+		}
+	}
+}
+
+// Iter returns iterator of value, ok is false if value cannot be iterated
+func Iter(value JValue) (*JIterator, bool) {
+	iterable, ok := value.(JIterable)
+	if !ok {
+		return nil, false
+	}
+
+	return iterable.Iter(), true
+}
+
+// newSliceIterator iterates elements of list or tuple, elements appended to list while iterating are visited
+func newSliceIterator(elementValues func() []JValue) *JIterator {
+	index := 0
+
+	return NewJIterator(func() (JValue, bool, error) {
+		values := elementValues()
+		if index >= len(values) {
+			return nil, false, nil
+		}
+
+		index++
+
+		return values[index-1], true, nil
+	})
+}
+
+func (l *JList) Iter() *JIterator {
+	return newSliceIterator(func() []JValue {
+		return l.ElementValues
+	})
+}
+
+func (t *JTuple) Iter() *JIterator {
+	return newSliceIterator(func() []JValue {
+		return t.ElementValues
+	})
+}
+
+// Iter iterates every byte of string as string
+func (s *JString) Iter() *JIterator {
+	value := s.Value.(string)
+	index := 0
+
+	return NewJIterator(func() (JValue, bool, error) {
+		if index >= len(value) {
+			return nil, false, nil
+		}
+
+		index++
+
+		return NewJString(value[index-1 : index]).SetJContext(s.Context), true, nil
+	})
+}
+
+// Iter iterates every byte of bytes as integer number
+func (b *JBytes) Iter() *JIterator {
+	value := b.Value.([]byte)
+	index := 0
+
+	return NewJIterator(func() (JValue, bool, error) {
+		if index >= len(value) {
+			return nil, false, nil
+		}
+
+		index++
+
+		return NewJNumber(int(value[index-1])).SetJContext(b.Context), true, nil
+	})
+}
+
+// Iter iterates keys of map in insertion order, the keys are taken when the iterator is created
+func (m *JMap) Iter() *JIterator {
+	keys := make([]JValue, 0, m.ElementMap.Size())
+	m.Range(func(key, _ JValue) bool {
+		keys = append(keys, key)
+
+		return true
+	})
+
+	return newSliceIterator(func() []JValue {
+		return keys
+	})
+}
+
+// ItemsIter iterates (key, value) tuples of map in insertion order, keys deleted while iterating are skipped
+func (m *JMap) ItemsIter() *JIterator {
+	keyIterator := m.Iter()
+
+	return NewJIterator(func() (JValue, bool, error) {
+		for {
+			key, ok, err := keyIterator.Next()
+			if err != nil || !ok {
+				return nil, false, err
+			}
+
+			if value, ok := m.Get(key); ok {
+				return NewJTuple([]JValue{key, value}).SetJContext(m.Context), true, nil
+			}
+		}
+	})
+}
@@ -11,12 +11,19 @@ import (
 type JList struct {
 	*JBaseValue
 	ElementValues []JValue
+	identity      *listIdentity
+}
+
+// listIdentity is shared by all references to the same list, see IsSame
+type listIdentity struct {
+	_ byte // distinct pointers to zero-size values may be equal
 }
 
 func NewJList(elementValues []JValue) *JList {
 	return &JList{
 		JBaseValue:    &JBaseValue{},
 		ElementValues: elementValues,
+		identity:      &listIdentity{},
 	}
 }
 
@@ -33,12 +40,17 @@ func (l *JList) SetJContext(context *common.JContext) JValue {
 	return l
 }
 
+// Copy returns a new reference to the list, element values are shared so that
+// index assignment through any variable is visible through all of them
 func (l *JList) Copy() JValue {
-	return NewJList(l.ElementValues)
+	list := NewJList(l.ElementValues)
+	list.identity = l.identity
+
+	return list
 }
 
 func (l *JList) AddTo(other JValue) (JValue, error) {
-	resList := l.deepCopy()
+	resList := l.shallowCopy()
 
 	switch otherValue := other.(type) {
 	case *JList:
@@ -51,7 +63,7 @@ func (l *JList) AddTo(other JValue) (JValue, error) {
 }
 
 func (l *JList) MulBy(other JValue) (JValue, error) {
-	resList := l.deepCopy()
+	resList := l.shallowCopy()
 
 	switch otherValue := other.(type) {
 	case *JList:
@@ -76,7 +88,7 @@ func (l *JList) MulBy(other JValue) (JValue, error) {
 }
 
 func (l *JList) SubBy(other JValue) (JValue, error) {
-	resList := l.deepCopy()
+	resList := l.shallowCopy()
 
 	if index, ok := other.GetValue().(int); ok {
 		if err := l.checkIndex(index, other); err != nil {
@@ -117,6 +129,18 @@ func (l *JList) IndexAssign(indexArg, indexValue JValue) (JValue, error) {
 	}
 }
 
+func (l *JList) SliceAccess(startArg, endArg JValue) (JValue, error) {
+	start, end, err := getSliceRange(startArg, endArg, len(l.ElementValues))
+	if err != nil {
+		return nil, err
+	}
+
+	elementValues := make([]JValue, end-start)
+	copy(elementValues, l.ElementValues[start:end])
+
+	return NewJList(elementValues).SetJContext(l.Context), nil
+}
+
 func (l *JList) String() string {
 	strBuilder := strings.Builder{}
 	strBuilder.WriteByte('[')
@@ -144,16 +168,10 @@ func (l *JList) IsAllNil() bool {
 	return true
 }
 
-func (l *JList) deepCopy() *JList {
+// shallowCopy returns a new list holding the same element values
+func (l *JList) shallowCopy() *JList {
 	elementValues := make([]JValue, len(l.ElementValues))
-	for index := range l.ElementValues {
-		elementValue := l.ElementValues[index]
-		if list, ok := elementValue.(*JList); ok {
-			elementValues[index] = list.deepCopy()
-		} else {
-			elementValues[index] = l.ElementValues[index]
-		}
-	}
+	copy(elementValues, l.ElementValues)
 
 	return NewJList(elementValues)
 }
@@ -166,9 +184,15 @@ func (l *JList) checkIndex(index int, arg JValue) error {
 				EndPos:   arg.GetEndPos(),
 			},
 			Context: l.Context,
+			Kind:    common.IndexError,
 			Details: "index integer number must >= 0 and < length of list",
 		}, "failed to index")
 	}
 
 	return nil
 }
+
+// Contains reports whether any element of list is equal to other
+func (l *JList) Contains(other JValue) (JValue, error) {
+	return NewJNumber(boolToNumber(containsEqual(l.ElementValues, other))).SetJContext(l.Context), nil
+}
@@ -1,19 +1,24 @@
 package object
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/IfanTsai/jirachi/common"
-	"github.com/IfanTsai/jirachi/pkg/safemap"
+	"github.com/IfanTsai/jirachi/pkg/orderedmap"
 )
 
+// JMapEntry is a key value pair of map, the key value is kept to be returned by keys and items
+type JMapEntry struct {
+	Key   JValue
+	Value JValue
+}
+
 type JMap struct {
 	*JBaseValue
-	ElementMap *safemap.SafeMap[JValue]
+	ElementMap *orderedmap.OrderedMap[JMapEntry] // hash key -> entry, in insertion order
 }
 
-func NewJMap(elementMap *safemap.SafeMap[JValue]) *JMap {
+func NewJMap(elementMap *orderedmap.OrderedMap[JMapEntry]) *JMap {
 	return &JMap{
 		JBaseValue: &JBaseValue{},
 		ElementMap: elementMap,
@@ -33,25 +38,43 @@ func (m *JMap) SetJContext(context *common.JContext) JValue {
 	return m
 }
 
+// Copy returns a new reference to the map, entries are shared like list
 func (m *JMap) Copy() JValue {
 	copyMap := NewJMap(m.ElementMap)
 
 	return copyMap
 }
 
+// Get returns the value of key, key must can be hashed
+func (m *JMap) Get(key JValue) (JValue, bool) {
+	entry, ok := m.ElementMap.Get(HashKey(key))
+
+	return entry.Value, ok
+}
+
+// Set sets the value of key, key must can be hashed
+func (m *JMap) Set(key, value JValue) {
+	m.ElementMap.Set(HashKey(key), JMapEntry{Key: key, Value: value})
+}
+
+// Del deletes key from map, returns false if key is not present. key must can be hashed
+func (m *JMap) Del(key JValue) bool {
+	return m.ElementMap.Del(HashKey(key))
+}
+
+// Range calls f sequentially for each key and value in insertion order
+func (m *JMap) Range(f func(key, value JValue) bool) {
+	m.ElementMap.Range(func(_ any, entry JMapEntry) bool {
+		return f(entry.Key, entry.Value)
+	})
+}
+
 func (m *JMap) IndexAccess(arg JValue) (JValue, error) {
 	if !CanHashed(arg) {
-		return nil, &common.JRunTimeError{
-			JError: &common.JError{
-				StartPos: m.StartPos,
-				EndPos:   m.EndPos,
-			},
-			Context: m.Context,
-			Details: "Cannot hashed",
-		}
+		return nil, m.createCannotHashedError(arg)
 	}
 
-	if resValue, ok := m.ElementMap.Get(arg.GetValue()); ok {
+	if resValue, ok := m.Get(arg); ok {
 		return resValue, nil
 	}
 
@@ -60,38 +83,41 @@ func (m *JMap) IndexAccess(arg JValue) (JValue, error) {
 
 func (m *JMap) IndexAssign(indexArg, indexValue JValue) (JValue, error) {
 	if !CanHashed(indexArg) {
-		return nil, &common.JRunTimeError{
-			JError: &common.JError{
-				StartPos: m.StartPos,
-				EndPos:   m.EndPos,
-			},
-			Context: m.Context,
-			Details: "Cannot hashed",
-		}
+		return nil, m.createCannotHashedError(indexArg)
 	}
 
-	if _, ok := indexValue.(*JNull); ok {
-		m.ElementMap.Del(indexArg.GetValue())
-	} else {
-		m.ElementMap.Set(indexArg.GetValue(), indexValue)
-	}
+	m.Set(indexArg, indexValue)
 
 	return m, nil
 }
 
-func (m *JMap) String() string {
+// Contains reports whether other is a key of map
+func (m *JMap) Contains(other JValue) (JValue, error) {
+	if !CanHashed(other) {
+		return nil, m.createCannotHashedError(other)
+	}
+
+	_, ok := m.Get(other)
+
+	return NewJNumber(boolToNumber(ok)).SetJContext(m.Context), nil
+}
 
+func (m *JMap) IsTrue() bool {
+	return m.ElementMap.Size() > 0
+}
+
+func (m *JMap) String() string {
 	strBuilder := strings.Builder{}
 	strBuilder.WriteByte('{')
 	firstKey := true
 
-	m.ElementMap.Range(func(key any, value JValue) bool {
+	m.Range(func(key, value JValue) bool {
 		if !firstKey {
 			strBuilder.WriteString(", ")
 		}
 
 		firstKey = false
-		strBuilder.WriteString(fmt.Sprintf("%v", key))
+		strBuilder.WriteString(key.String())
 		strBuilder.WriteString(": ")
 		strBuilder.WriteString(value.String())
 
@@ -102,3 +128,16 @@ func (m *JMap) String() string {
 
 	return strBuilder.String()
 }
+
+// createCannotHashedError creates error at key, because maps created by built-in functions may have no position
+func (m *JMap) createCannotHashedError(key JValue) error {
+	return &common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: key.GetStartPos(),
+			EndPos:   key.GetEndPos(),
+		},
+		Context: m.Context,
+		Kind:    common.TypeError,
+		Details: "Cannot hashed",
+	}
+}
@@ -135,6 +135,7 @@ func (n *JNumber) DivBy(other JValue) (JValue, error) {
 					EndPos:   otherNumber.EndPos,
 				},
 				Context: n.Context,
+				Kind:    common.ZeroDivisionError,
 				Details: "Division by zero",
 			}, "failed to div")
 		}
@@ -152,6 +153,7 @@ func (n *JNumber) DivBy(other JValue) (JValue, error) {
 					EndPos:   otherNumber.EndPos,
 				},
 				Context: n.Context,
+				Kind:    common.ZeroDivisionError,
 				Details: "Division by zero",
 			}, "failed to div")
 		}
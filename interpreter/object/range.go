@@ -0,0 +1,146 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+)
+
+// JRange is a lazy sequence of integer numbers from Start (inclusive) to End (exclusive) by Step
+type JRange struct {
+	*JBaseValue
+	Start int
+	End   int
+	Step  int
+}
+
+func NewJRange(start, end, step int) *JRange {
+	return &JRange{
+		JBaseValue: &JBaseValue{},
+		Start:      start,
+		End:        end,
+		Step:       step,
+	}
+}
+
+// MakeJRange creates range from number values, stepValue can be nil which means 1
+func MakeJRange(startValue, endValue, stepValue JValue) (JValue, error) {
+	start, ok := startValue.GetValue().(int)
+	if !ok {
+		return nil, createNumberTypeError(startValue, "make range")
+	}
+
+	end, ok := endValue.GetValue().(int)
+	if !ok {
+		return nil, createNumberTypeError(endValue, "make range")
+	}
+
+	step := 1
+	if stepValue != nil {
+		if step, ok = stepValue.GetValue().(int); !ok {
+			return nil, createNumberTypeError(stepValue, "make range")
+		}
+
+		if step == 0 {
+			return nil, errors.Wrap(&common.JRunTimeError{
+				JError: &common.JError{
+					StartPos: stepValue.GetStartPos(),
+					EndPos:   stepValue.GetEndPos(),
+				},
+				Context: stepValue.GetContext(),
+				Kind:    common.ValueError,
+				Details: "step of range must not be zero",
+			}, "failed to make range")
+		}
+	}
+
+	return NewJRange(start, end, step).SetJContext(startValue.GetContext()), nil
+}
+
+func (r *JRange) SetJPos(startPos, endPos *common.JPosition) JValue {
+	r.StartPos = startPos
+	r.EndPos = endPos
+
+	return r
+}
+
+func (r *JRange) SetJContext(context *common.JContext) JValue {
+	r.Context = context
+
+	return r
+}
+
+func (r *JRange) Copy() JValue {
+	return NewJRange(r.Start, r.End, r.Step)
+}
+
+func (r *JRange) String() string {
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// Len returns count of numbers in range
+func (r *JRange) Len() int {
+	if r.Step > 0 && r.Start < r.End {
+		return (r.End - r.Start + r.Step - 1) / r.Step
+	}
+
+	if r.Step < 0 && r.Start > r.End {
+		return (r.Start - r.End - r.Step - 1) / -r.Step
+	}
+
+	return 0
+}
+
+func (r *JRange) IsTrue() bool {
+	return r.Len() > 0
+}
+
+func (r *JRange) IndexAccess(arg JValue) (JValue, error) {
+	index, ok := arg.GetValue().(int)
+	if !ok {
+		return nil, createNumberTypeError(arg, "index")
+	}
+
+	if index < 0 || index >= r.Len() {
+		return nil, errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: arg.GetStartPos(),
+				EndPos:   arg.GetEndPos(),
+			},
+			Context: r.Context,
+			Kind:    common.IndexError,
+			Details: "index integer number must >= 0 and < length of range",
+		}, "failed to index")
+	}
+
+	return NewJNumber(r.Start + index*r.Step).SetJContext(r.Context), nil
+}
+
+// Contains reports whether other is an integer number of range, it doesn't iterate the range
+func (r *JRange) Contains(other JValue) (JValue, error) {
+	res := false
+	if value, ok := other.GetValue().(int); ok && r.Len() > 0 {
+		offset := value - r.Start
+		res = offset%r.Step == 0 && offset/r.Step >= 0 && offset/r.Step < r.Len()
+	}
+
+	return NewJNumber(boolToNumber(res)).SetJContext(r.Context), nil
+}
+
+func (r *JRange) Iter() *JIterator {
+	current, count := r.Start, r.Len()
+
+	return NewJIterator(func() (JValue, bool, error) {
+		if count <= 0 {
+			return nil, false, nil
+		}
+
+		value := current
+		current += r.Step
+		count--
+
+		return NewJNumber(value).SetJContext(r.Context), true, nil
+	})
+}
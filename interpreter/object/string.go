@@ -99,6 +99,78 @@ func (s *JString) IndexAccess(arg JValue) (JValue, error) {
 	}
 }
 
+func (s *JString) SliceAccess(startArg, endArg JValue) (JValue, error) {
+	start, end, err := getSliceRange(startArg, endArg, len(s.Value.(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJString(s.Value.(string)[start:end]).SetJContext(s.Context), nil
+}
+
+func (s *JString) EqualTo(other JValue) (JValue, error) {
+	res, err := s.compare(other, "compare equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res == 0)).SetJContext(s.Context), nil
+}
+
+func (s *JString) NotEqualTo(other JValue) (JValue, error) {
+	res, err := s.compare(other, "compare not equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res != 0)).SetJContext(s.Context), nil
+}
+
+func (s *JString) LessThan(other JValue) (JValue, error) {
+	res, err := s.compare(other, "compare less than")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res < 0)).SetJContext(s.Context), nil
+}
+
+func (s *JString) LessThanOrEqualTo(other JValue) (JValue, error) {
+	res, err := s.compare(other, "compare less than or equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res <= 0)).SetJContext(s.Context), nil
+}
+
+func (s *JString) GreaterThan(other JValue) (JValue, error) {
+	res, err := s.compare(other, "compare greater than")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res > 0)).SetJContext(s.Context), nil
+}
+
+func (s *JString) GreaterThanOrEqualTo(other JValue) (JValue, error) {
+	res, err := s.compare(other, "compare greater than or equal")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJNumber(boolToNumber(res >= 0)).SetJContext(s.Context), nil
+}
+
+func (s *JString) compare(other JValue, operation string) (int, error) {
+	otherString, ok := other.(*JString)
+	if !ok {
+		return 0, s.createIllegalOperationError(other, operation)
+	}
+
+	return strings.Compare(s.Value.(string), otherString.Value.(string)), nil
+}
+
 func (s *JString) checkIndex(index int, arg JValue) error {
 	if index < 0 || index >= len(s.Value.(string)) {
 		return errors.Wrap(&common.JRunTimeError{
@@ -107,9 +179,20 @@ func (s *JString) checkIndex(index int, arg JValue) error {
 				EndPos:   arg.GetEndPos(),
 			},
 			Context: s.Context,
+			Kind:    common.IndexError,
 			Details: "index integer number must >= 0 and < length of string",
 		}, "failed to index")
 	}
 
 	return nil
 }
+
+// Contains reports whether other is a substring of string
+func (s *JString) Contains(other JValue) (JValue, error) {
+	subStr, ok := other.GetValue().(string)
+	if !ok {
+		return nil, s.createIllegalOperationError(s, "contains")
+	}
+
+	return NewJNumber(boolToNumber(strings.Contains(s.Value.(string), subStr))).SetJContext(s.Context), nil
+}
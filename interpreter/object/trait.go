@@ -0,0 +1,79 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/pkg/safemap"
+)
+
+// JTrait is trait declared by trait statement, JBaseValue.Value is the trait name.
+// MethodArgNames[i] are args of MethodNames[i], including self
+type JTrait struct {
+	*JBaseValue
+	MethodNames    []string
+	MethodArgNames [][]string
+	impls          *safemap.SafeMap[struct{}] // type keys of enums and types which implement the trait
+}
+
+func NewJTrait(name string, methodNames []string, methodArgNames [][]string) *JTrait {
+	return &JTrait{
+		JBaseValue: &JBaseValue{
+			Value: name,
+		},
+		MethodNames:    methodNames,
+		MethodArgNames: methodArgNames,
+		impls:          safemap.NewSafeMap[struct{}](),
+	}
+}
+
+func (t *JTrait) SetJPos(startPos, endPos *common.JPosition) JValue {
+	t.StartPos = startPos
+	t.EndPos = endPos
+
+	return t
+}
+
+func (t *JTrait) SetJContext(context *common.JContext) JValue {
+	t.Context = context
+
+	return t
+}
+
+// Copy returns the trait itself, so that impl blocks are shared
+func (t *JTrait) Copy() JValue {
+	return t
+}
+
+func (t *JTrait) String() string {
+	return fmt.Sprintf("<trait %v>", t.Value)
+}
+
+func (t *JTrait) IsTrue() bool {
+	return true
+}
+
+// AddImpl records that values of type key implement the trait, see TypeKey
+func (t *JTrait) AddImpl(typeKey any) {
+	t.impls.Set(typeKey, struct{}{})
+}
+
+// IsImplementedBy reports whether trait is implemented for type of value
+func (t *JTrait) IsImplementedBy(value JValue) bool {
+	_, ok := t.impls.Get(TypeKey(value))
+
+	return ok
+}
+
+// TypeKey returns key which methods of impl blocks are registered with, members of enum use the enum
+// so that each enum is a type, others use their type name and built-in functions are functions
+func TypeKey(value JValue) any {
+	switch value := value.(type) {
+	case *JEnumMember:
+		return value.Enum
+	case *JBuiltInFunction:
+		return Function
+	}
+
+	return GetJValueType(value)
+}
@@ -0,0 +1,219 @@
+package object
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+)
+
+type JTuple struct {
+	*JBaseValue
+	ElementValues []JValue
+}
+
+func NewJTuple(elementValues []JValue) *JTuple {
+	return &JTuple{
+		JBaseValue:    &JBaseValue{},
+		ElementValues: elementValues,
+	}
+}
+
+func (t *JTuple) SetJPos(startPos, endPos *common.JPosition) JValue {
+	t.StartPos = startPos
+	t.EndPos = endPos
+
+	return t
+}
+
+func (t *JTuple) SetJContext(context *common.JContext) JValue {
+	t.Context = context
+
+	return t
+}
+
+// Copy shares the element values, a tuple is immutable so nobody can observe it
+func (t *JTuple) Copy() JValue {
+	return NewJTuple(t.ElementValues)
+}
+
+func (t *JTuple) IndexAccess(arg JValue) (JValue, error) {
+	if index, ok := arg.GetValue().(int); ok {
+		if index < 0 || index >= len(t.ElementValues) {
+			return nil, errors.Wrap(&common.JRunTimeError{
+				JError: &common.JError{
+					StartPos: arg.GetStartPos(),
+					EndPos:   arg.GetEndPos(),
+				},
+				Context: t.Context,
+				Kind:    common.IndexError,
+				Details: "index integer number must >= 0 and < length of tuple",
+			}, "failed to index")
+		}
+
+		return t.ElementValues[index], nil
+	} else {
+		return nil, createNumberTypeError(arg, "index")
+	}
+}
+
+func (t *JTuple) IndexAssign(indexArg, indexValue JValue) (JValue, error) {
+	return nil, errors.Wrap(&common.JRunTimeError{
+		JError: &common.JError{
+			StartPos: t.StartPos,
+			EndPos:   indexValue.GetEndPos(),
+		},
+		Context: t.Context,
+		Kind:    common.TypeError,
+		Details: "tuple is immutable",
+	}, "failed to index assign")
+}
+
+func (t *JTuple) SliceAccess(startArg, endArg JValue) (JValue, error) {
+	start, end, err := getSliceRange(startArg, endArg, len(t.ElementValues))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJTuple(t.ElementValues[start:end]).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) EqualTo(other JValue) (JValue, error) {
+	res, err := t.compare(other, true)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compare equal")
+	}
+
+	return NewJNumber(boolToNumber(res == 0)).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) NotEqualTo(other JValue) (JValue, error) {
+	res, err := t.compare(other, true)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compare not equal")
+	}
+
+	return NewJNumber(boolToNumber(res != 0)).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) LessThan(other JValue) (JValue, error) {
+	res, err := t.compare(other, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compare less than")
+	}
+
+	return NewJNumber(boolToNumber(res < 0)).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) LessThanOrEqualTo(other JValue) (JValue, error) {
+	res, err := t.compare(other, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compare less than or equal")
+	}
+
+	return NewJNumber(boolToNumber(res <= 0)).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) GreaterThan(other JValue) (JValue, error) {
+	res, err := t.compare(other, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compare greater than")
+	}
+
+	return NewJNumber(boolToNumber(res > 0)).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) GreaterThanOrEqualTo(other JValue) (JValue, error) {
+	res, err := t.compare(other, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compare greater than or equal")
+	}
+
+	return NewJNumber(boolToNumber(res >= 0)).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) IsTrue() bool {
+	return len(t.ElementValues) > 0
+}
+
+func (t *JTuple) Not() (JValue, error) {
+	return NewJNumber(boolToNumber(!t.IsTrue())).SetJContext(t.Context), nil
+}
+
+func (t *JTuple) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteByte('(')
+	for index, element := range t.ElementValues {
+		if index != 0 {
+			strBuilder.WriteString(", ")
+		}
+
+		strBuilder.WriteString(element.String())
+	}
+
+	if len(t.ElementValues) == 1 {
+		strBuilder.WriteByte(',')
+	}
+	strBuilder.WriteByte(')')
+
+	return strBuilder.String()
+}
+
+// compare compares tuples lexicographically, returns -1, 0 or 1.
+// elements of different types are only allowed when checking equality, where they are simply not equal
+func (t *JTuple) compare(other JValue, isEquality bool) (int, error) {
+	otherTuple, ok := other.(*JTuple)
+	if !ok {
+		if isEquality {
+			return 1, nil
+		}
+
+		return 0, t.createIllegalOperationError(other, "compare")
+	}
+
+	for index := 0; index < len(t.ElementValues) && index < len(otherTuple.ElementValues); index++ {
+		element, otherElement := t.ElementValues[index], otherTuple.ElementValues[index]
+		if isEquality && GetJValueType(element) != GetJValueType(otherElement) {
+			return 1, nil
+		}
+
+		equalValue, err := element.EqualTo(otherElement)
+		if err != nil {
+			return 0, err
+		}
+
+		if equalValue.IsTrue() {
+			continue
+		}
+
+		if isEquality {
+			return 1, nil
+		}
+
+		lessValue, err := element.LessThan(otherElement)
+		if err != nil {
+			return 0, err
+		}
+
+		if lessValue.IsTrue() {
+			return -1, nil
+		}
+
+		return 1, nil
+	}
+
+	switch {
+	case len(t.ElementValues) < len(otherTuple.ElementValues):
+		return -1, nil
+	case len(t.ElementValues) > len(otherTuple.ElementValues):
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Contains reports whether any element of tuple is equal to other
+func (t *JTuple) Contains(other JValue) (JValue, error) {
+	return NewJNumber(boolToNumber(containsEqual(t.ElementValues, other))).SetJContext(t.Context), nil
+}
@@ -1,27 +1,291 @@
 package object
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/pkg/orderedmap"
+)
+
 func GetJValueType(arg JValue) string {
 	switch arg.(type) {
 	case *JNumber:
 		return Number
 	case *JString:
 		return String
+	case *JBytes:
+		return Bytes
 	case *JList:
 		return List
+	case *JTuple:
+		return Tuple
+	case *JMap:
+		return Map
+	case *JRange:
+		return Range
+	case *JIterator:
+		return Iterator
+	case *JErrorValue:
+		return Error
+	case *JEnum:
+		return Enum
+	case *JEnumMember:
+		return EnumMember
+	case *JTrait:
+		return Trait
+	case *JAst:
+		return Ast
 	case *JFunction:
 		return Function
 	case *JBuiltInFunction:
 		return BuiltInFunction
+	case *JNull:
+		return Null
 	}
 
 	return Unknow
 }
 
+// TypeMatches reports whether value of type actual can be used where type expected is annotated,
+// any matches all types and function matches built-in function
+func TypeMatches(expected, actual string) bool {
+	return expected == Any || expected == actual || (expected == Function && actual == BuiltInFunction)
+}
+
+// IsType reports whether value matches type annotation typeName, null only matches any
+func IsType(value JValue, typeName string) bool {
+	if value == nil || IsNull(value) {
+		return typeName == Any
+	}
+
+	return TypeMatches(typeName, GetJValueType(value))
+}
+
 func CanHashed(arg JValue) bool {
 	argType := GetJValueType(arg)
-	if argType == String || argType == Number {
+	if argType == String || argType == Number || argType == EnumMember {
+		return true
+	}
+
+	if tuple, ok := arg.(*JTuple); ok {
+		for _, elementValue := range tuple.ElementValues {
+			if !CanHashed(elementValue) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// tupleKey is the comparable key of a hashable tuple, two tuples have the same key
+// only if their elements have the same types and values
+type tupleKey struct {
+	key string
+}
+
+// enumMemberKey is the comparable key of a member of enum
+type enumMemberKey struct {
+	enumName   any
+	memberName any
+}
+
+// HashKey returns the key used to store arg in a map, arg must can be hashed
+func HashKey(arg JValue) any {
+	if member, ok := arg.(*JEnumMember); ok {
+		return enumMemberKey{enumName: member.Enum.Value, memberName: member.Value}
+	}
+
+	tuple, ok := arg.(*JTuple)
+	if !ok {
+		return arg.GetValue()
+	}
+
+	keyBuilder := strings.Builder{}
+	for _, elementValue := range tuple.ElementValues {
+		keyBuilder.WriteString(fmt.Sprintf("%T:%#v;", HashKey(elementValue), HashKey(elementValue)))
+	}
+
+	return tupleKey{key: keyBuilder.String()}
+}
+
+// IsNull reports whether value is null or the empty value of expressions such as 'if false then 1'
+func IsNull(value JValue) bool {
+	switch v := value.(type) {
+	case *JNull:
 		return true
+	case *JNumber:
+		return v.Value == nil
+	}
+
+	return value == nil
+}
+
+// Equals reports whether a == b, values which cannot be compared with each other are not equal
+func Equals(a, b JValue) bool {
+	if _, ok := b.(*JNull); ok {
+		a, b = b, a
+	}
+
+	res, err := a.EqualTo(b)
+
+	return err == nil && res.IsTrue()
+}
+
+func containsEqual(elementValues []JValue, value JValue) bool {
+	for _, elementValue := range elementValues {
+		if Equals(elementValue, value) {
+			return true
+		}
 	}
 
 	return false
 }
+
+// IsSame reports whether a and b are the same value: mutable containers, functions and iterators are the same
+// only if they are the same reference, immutable values are the same if they have the same type and are equal
+func IsSame(a, b JValue) bool {
+	switch aValue := a.(type) {
+	case *JNull:
+		_, ok := b.(*JNull)
+
+		return ok
+	case *JList:
+		bValue, ok := b.(*JList)
+
+		return ok && aValue.identity == bValue.identity
+	case *JMap:
+		bValue, ok := b.(*JMap)
+
+		return ok && aValue.ElementMap == bValue.ElementMap
+	case *JFunction:
+		bValue, ok := b.(*JFunction)
+
+		return ok && aValue.BodyNode == bValue.BodyNode && aValue.Value == bValue.Value &&
+			aValue.Closure == bValue.Closure
+	case *JBuiltInFunction:
+		bValue, ok := b.(*JBuiltInFunction)
+
+		return ok && aValue.Value == bValue.Value
+	case *JRange:
+		bValue, ok := b.(*JRange)
+
+		return ok && aValue.Start == bValue.Start && aValue.End == bValue.End && aValue.Step == bValue.Step
+	case *JIterator:
+		return a == b
+	}
+
+	return GetJValueType(a) == GetJValueType(b) && Equals(a, b)
+}
+
+// ShallowCopy returns a new container holding the same elements as value,
+// values which are not mutable containers are returned as they are
+func ShallowCopy(value JValue) JValue {
+	switch v := value.(type) {
+	case *JList:
+		return v.shallowCopy().SetJContext(v.Context)
+	case *JMap:
+		return NewJMap(v.ElementMap.Copy()).SetJPos(v.StartPos, v.EndPos).SetJContext(v.Context)
+	}
+
+	return value
+}
+
+// DeepCopy recursively copies value and all containers reachable from it,
+// containers referenced several times (even cyclically) are copied once
+func DeepCopy(value JValue) JValue {
+	return deepCopy(value, make(map[JValue]JValue))
+}
+
+func deepCopy(value JValue, copied map[JValue]JValue) JValue {
+	if copiedValue, ok := copied[value]; ok {
+		return copiedValue
+	}
+
+	switch v := value.(type) {
+	case *JList:
+		resList := NewJList(make([]JValue, len(v.ElementValues)))
+		resList.SetJContext(v.Context)
+		copied[value] = resList
+
+		for index := range v.ElementValues {
+			resList.ElementValues[index] = deepCopy(v.ElementValues[index], copied)
+		}
+
+		return resList
+	case *JTuple:
+		resTuple := NewJTuple(make([]JValue, len(v.ElementValues)))
+		resTuple.SetJContext(v.Context)
+		copied[value] = resTuple
+
+		for index := range v.ElementValues {
+			resTuple.ElementValues[index] = deepCopy(v.ElementValues[index], copied)
+		}
+
+		return resTuple
+	case *JMap:
+		resMap := NewJMap(orderedmap.NewOrderedMap[JMapEntry]())
+		resMap.SetJPos(v.StartPos, v.EndPos).SetJContext(v.Context)
+		copied[value] = resMap
+
+		v.Range(func(key, value JValue) bool {
+			resMap.Set(key, deepCopy(value, copied))
+
+			return true
+		})
+
+		return resMap
+	}
+
+	return value
+}
+
+// getSliceRange converts slice arguments to a valid [start, end) range of a sequence,
+// nil arguments mean the beginning and the end of the sequence
+func getSliceRange(startArg, endArg JValue, length int) (int, int, error) {
+	start, end := 0, length
+
+	if startArg != nil {
+		index, ok := startArg.GetValue().(int)
+		if !ok {
+			return 0, 0, createNumberTypeError(startArg, "slice")
+		}
+
+		start = index
+	}
+
+	if endArg != nil {
+		index, ok := endArg.GetValue().(int)
+		if !ok {
+			return 0, 0, createNumberTypeError(endArg, "slice")
+		}
+
+		end = index
+	}
+
+	var errArg JValue
+	if start < 0 || start > length {
+		errArg = startArg
+	} else if end < start || end > length {
+		errArg = endArg
+	}
+
+	if errArg != nil {
+		return 0, 0, errors.Wrap(&common.JRunTimeError{
+			JError: &common.JError{
+				StartPos: errArg.GetStartPos(),
+				EndPos:   errArg.GetEndPos(),
+			},
+			Context: errArg.GetContext(),
+			Kind:    common.IndexError,
+			Details: "slice range must satisfy 0 <= start <= end <= length",
+		}, "failed to slice")
+	}
+
+	return start, end, nil
+}
@@ -35,6 +35,8 @@ type JValue interface {
 	Execute(args []JValue) (JValue, error)
 	IndexAccess(arg JValue) (JValue, error)
 	IndexAssign(indexArg, indexValue JValue) (JValue, error)
+	SliceAccess(startArg, endArg JValue) (JValue, error)
+	Contains(other JValue) (JValue, error)
 }
 
 type JBaseValue struct {
@@ -153,6 +155,14 @@ func (v *JBaseValue) IndexAssign(indexArg, indexValue JValue) (JValue, error) {
 	return nil, v.createIllegalOperationError(v, "index assign")
 }
 
+func (v *JBaseValue) SliceAccess(startArg, endArg JValue) (JValue, error) {
+	return nil, v.createIllegalOperationError(v, "slice access")
+}
+
+func (v *JBaseValue) Contains(other JValue) (JValue, error) {
+	return nil, v.createIllegalOperationError(v, "contains")
+}
+
 func (v *JBaseValue) createIllegalOperationError(value JValue, operation string) error {
 	return errors.Wrap(&common.JRunTimeError{
 		JError: &common.JError{
@@ -160,6 +170,7 @@ func (v *JBaseValue) createIllegalOperationError(value JValue, operation string)
 			EndPos:   value.GetEndPos(),
 		},
 		Context: v.GetContext(),
+		Kind:    common.TypeError,
 		Details: "Illegal operation",
 	}, "failed to "+operation)
 }
@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/interpreter/object"
+	"github.com/IfanTsai/jirachi/parser"
+)
+
+// visitShellNode builds command of shell literal and runs it with @. Interpolated values are shell-quoted,
+// lists are expanded to one quoted word per element, raw values are inserted as they are. Values inside
+// double quotes of shell are escaped instead, because single quotes don't quote there
+func (i *JInterpreter) visitShellNode(node *parser.JShellNode) (object.JValue, error) {
+	var command strings.Builder
+
+	command.WriteString(node.Fragments[0])
+
+	for index, valueNode := range node.ValueNodes {
+		value, err := i.visit(valueNode)
+		if err != nil {
+			return nil, err
+		}
+
+		words, ok := shellWords(value)
+		if !ok {
+			return nil, errors.Wrap(&common.JRunTimeError{
+				JError: &common.JError{
+					StartPos: node.StartPos,
+					EndPos:   node.EndPos,
+				},
+				Context: i.Context,
+				Kind:    common.TypeError,
+				Details: fmt.Sprintf("cannot interpolate %s into shell command, expected string, number or list",
+					getTypeName(value)),
+			}, "failed to visit shell node")
+		}
+
+		switch {
+		case node.RawValues[index]:
+		case node.DoubleQuoted[index]:
+			for wordIndex, word := range words {
+				words[wordIndex] = shellEscapeDoubleQuoted(word)
+			}
+		default:
+			for wordIndex, word := range words {
+				words[wordIndex] = shellQuote(word)
+			}
+		}
+
+		command.WriteString(strings.Join(words, " "))
+		command.WriteString(node.Fragments[index+1])
+	}
+
+	callValue, err := i.visit(node.CallNode)
+	if err != nil {
+		return nil, err
+	}
+
+	commandValue := object.NewJString(command.String()).SetJPos(node.StartPos, node.EndPos).SetJContext(i.Context)
+
+	resValue, err := callFunction(callValue, []object.JValue{commandValue})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to visit shell node")
+	}
+
+	return resValue, nil
+}
+
+// shellWords converts string or number to a word and list of strings or numbers to words
+func shellWords(value object.JValue) ([]string, bool) {
+	switch value := value.(type) {
+	case *object.JString:
+		return []string{value.Value.(string)}, true
+	case *object.JNumber:
+		return []string{value.String()}, true
+	case *object.JList:
+		words := make([]string, 0, len(value.ElementValues))
+		for _, elementValue := range value.ElementValues {
+			switch elementValue.(type) {
+			case *object.JString, *object.JNumber:
+				elementWords, _ := shellWords(elementValue)
+				words = append(words, elementWords...)
+			default:
+				return nil, false
+			}
+		}
+
+		return words, true
+	}
+
+	return nil, false
+}
+
+// shellQuote quotes word with single quotes unless it only contains characters which are safe for shell
+func shellQuote(word string) string {
+	if word == "" {
+		return "''"
+	}
+
+	isSafe := strings.IndexFunc(word, func(char rune) bool {
+		return !('a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || '0' <= char && char <= '9' ||
+			strings.ContainsRune("@%+=:,./_-", char))
+	}) < 0
+	if isSafe {
+		return word
+	}
+
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// shellEscapeDoubleQuoted escapes characters which are special inside double quotes of shell
+func shellEscapeDoubleQuoted(word string) string {
+	var builder strings.Builder
+
+	for _, char := range word {
+		if strings.ContainsRune("$`\"\\", char) {
+			builder.WriteByte('\\')
+		}
+
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
@@ -18,17 +18,28 @@ var escapeChars = map[byte]byte{
 }
 
 type JLexer struct {
-	Text []byte
-	Pos  *common.JPosition
+	Text         []byte
+	Pos          *common.JPosition
+	docs         map[int64]string // text of consecutive ## comment lines, keyed by line of the last comment
+	keepComments bool
 }
 
 func NewJLexer(filename, text string) *JLexer {
 	return &JLexer{
 		Text: []byte(text),
 		Pos:  common.NewJPosition(-1, -1, 0, filename, text),
+		docs: make(map[int64]string),
 	}
 }
 
+// KeepComments makes the lexer emit COMMENT tokens instead of dropping comments,
+// so that tools such as formatters can work from the tokens. The parser doesn't accept COMMENT tokens
+func (l *JLexer) KeepComments() *JLexer {
+	l.keepComments = true
+
+	return l
+}
+
 func (l *JLexer) MakeTokens() ([]*token.JToken, error) {
 	var (
 		err error
@@ -43,7 +54,13 @@ func (l *JLexer) MakeTokens() ([]*token.JToken, error) {
 		case char == ' ' || char == '\t':
 			advanceAble = l.advance()
 		case char == '#':
-			advanceAble = l.skipComment()
+			tok, advanceAble, err = l.makeComment(isLineStart(tokens))
+			if err != nil {
+				return nil, err
+			}
+			if l.keepComments {
+				tokens = append(tokens, tok)
+			}
 		case char == ';' || char == '\n':
 			tokens = append(tokens, token.NewJToken(token.NEWLINE, nil, l.Pos, l.Pos))
 			advanceAble = l.advance()
@@ -53,6 +70,12 @@ func (l *JLexer) MakeTokens() ([]*token.JToken, error) {
 				return nil, err
 			}
 			tokens = append(tokens, tok)
+		case char == 'b' && l.isNextQuote():
+			tok, advanceAble, err = l.makeBytes()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
 		case isLetters(char), isAt(char):
 			tok, advanceAble = l.makeIdentifierToken()
 			tokens = append(tokens, tok)
@@ -116,6 +139,21 @@ func (l *JLexer) MakeTokens() ([]*token.JToken, error) {
 		case char == ',':
 			tokens = append(tokens, token.NewJToken(token.COMMA, nil, l.Pos, l.Pos))
 			advanceAble = l.advance()
+		case char == '.':
+			tok, advanceAble = l.makeDotToken()
+			tokens = append(tokens, tok)
+		case char == '|':
+			tok, advanceAble, err = l.makePipeToken()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+		case char == '?':
+			tok, advanceAble, err = l.makeQuestionToken()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
 		default:
 			startPos := l.Pos.Copy()
 			l.advance()
@@ -131,6 +169,7 @@ func (l *JLexer) MakeTokens() ([]*token.JToken, error) {
 	}
 
 	tokens = append(tokens, token.NewJToken(token.EOF, nil, l.Pos, l.Pos))
+	l.attachDocs(tokens)
 
 	return tokens, nil
 }
@@ -149,7 +188,8 @@ func (l *JLexer) makeNumberToken() (*token.JToken, bool, error) {
 		}
 
 		if char == '.' {
-			if isFloat {
+			// eg. 1..10 is a range rather than a float number
+			if isFloat || l.isNextChar('.') {
 				break
 			}
 
@@ -211,14 +251,33 @@ func (l *JLexer) makeIdentifierToken() (*token.JToken, bool) {
 }
 
 func (l *JLexer) makeString() (*token.JToken, bool, error) {
+	return l.makeQuotedToken(token.STRING, l.Pos.Copy())
+}
+
+// makeBytes makes bytes literal token, eg. b"\x00\x01", which supports \xHH escape
+func (l *JLexer) makeBytes() (*token.JToken, bool, error) {
+	startPos := l.Pos.Copy()
+	l.advance()
+
+	return l.makeQuotedToken(token.BYTES, startPos)
+}
+
+func (l *JLexer) makeQuotedToken(tokenType token.JTokenType, startPos *common.JPosition) (*token.JToken, bool, error) {
 	quote := l.getCurrentChar() // ' or "
 	strBuilder := strings.Builder{}
-	startPos := l.Pos.Copy()
 	isEscape := false
 	advanceAble := l.advance()
 
 	for advanceAble && (l.getCurrentChar() != quote || isEscape) {
-		if isEscape {
+		if isEscape && tokenType == token.BYTES && l.getCurrentChar() == 'x' {
+			hexByte, err := l.makeHexEscapeByte()
+			if err != nil {
+				return nil, false, err
+			}
+			strBuilder.WriteByte(hexByte)
+
+			isEscape = false
+		} else if isEscape {
 			escapeChar, ok := escapeChars[l.getCurrentChar()]
 			if !ok {
 				escapeChar = l.getCurrentChar()
@@ -254,7 +313,30 @@ func (l *JLexer) makeString() (*token.JToken, bool, error) {
 
 	advanceAble = l.advance()
 
-	return token.NewJToken(token.STRING, strBuilder.String(), startPos, l.Pos), advanceAble, nil
+	return token.NewJToken(tokenType, strBuilder.String(), startPos, l.Pos), advanceAble, nil
+}
+
+// makeHexEscapeByte reads two hex digits following \x, the current char is x
+func (l *JLexer) makeHexEscapeByte() (byte, error) {
+	var hexDigits [2]byte
+	for index := range hexDigits {
+		startPos := l.Pos.Copy()
+		if !l.advance() || !isHexDigit(l.getCurrentChar()) {
+			return 0, errors.Wrap(&common.JInvalidSyntaxError{
+				JError: &common.JError{
+					StartPos: startPos,
+					EndPos:   startPos.Copy().Advance(l.Text),
+				},
+				Details: "Expected two hex digits after '\\x'",
+			}, "failed to make hex escape")
+		}
+
+		hexDigits[index] = l.getCurrentChar()
+	}
+
+	hexByte, _ := strconv.ParseUint(string(hexDigits[:]), 16, 8)
+
+	return byte(hexByte), nil
 }
 
 func (l *JLexer) makeMinusOrArrowToken() (*token.JToken, bool) {
@@ -271,6 +353,72 @@ func (l *JLexer) makeMinusOrArrowToken() (*token.JToken, bool) {
 	return token.NewJToken(tokenType, nil, startPos, l.Pos), advanceAble
 }
 
+func (l *JLexer) makeDotToken() (*token.JToken, bool) {
+	startPos := l.Pos.Copy()
+	advanceAble := l.advance()
+	tokenType := token.DOT
+
+	if advanceAble && l.getCurrentChar() == '.' {
+		advanceAble = l.advance()
+
+		tokenType = token.DOTDOT
+	}
+
+	return token.NewJToken(tokenType, nil, startPos, l.Pos), advanceAble
+}
+
+// makeQuestionToken makes ?. ?[ or ??
+func (l *JLexer) makeQuestionToken() (*token.JToken, bool, error) {
+	startPos := l.Pos.Copy()
+	advanceAble := l.advance()
+
+	if advanceAble {
+		var tokenType token.JTokenType
+
+		switch l.getCurrentChar() {
+		case '.':
+			tokenType = token.QDOT
+		case '[':
+			tokenType = token.QLSQUARE
+		case '?':
+			tokenType = token.QQ
+		}
+
+		if tokenType != "" {
+			advanceAble = l.advance()
+
+			return token.NewJToken(tokenType, nil, startPos, l.Pos), advanceAble, nil
+		}
+	}
+
+	return nil, advanceAble, errors.Wrap(&common.JInvalidSyntaxError{
+		JError: &common.JError{
+			StartPos: startPos,
+			EndPos:   l.Pos,
+		},
+		Details: "Expected '?.', '?[' or '??'",
+	}, "failed to make question token")
+}
+
+func (l *JLexer) makePipeToken() (*token.JToken, bool, error) {
+	startPos := l.Pos.Copy()
+	advanceAble := l.advance()
+
+	if advanceAble && l.getCurrentChar() == '>' {
+		advanceAble = l.advance()
+
+		return token.NewJToken(token.PIPE, nil, startPos, l.Pos), advanceAble, nil
+	}
+
+	return nil, advanceAble, errors.Wrap(&common.JExpectedCharacterError{
+		JError: &common.JError{
+			StartPos: startPos,
+			EndPos:   l.Pos,
+		},
+		ExpectedChar: '>',
+	}, "failed to make pipe token")
+}
+
 func (l *JLexer) makeNotEqualToken() (*token.JToken, bool, error) {
 	startPos := l.Pos.Copy()
 	advanceAble := l.advance()
@@ -332,14 +480,111 @@ func (l *JLexer) makeGreaterThanToken() (*token.JToken, bool) {
 	return token.NewJToken(tokenType, nil, startPos, l.Pos), advanceAble
 }
 
-func (l *JLexer) skipComment() bool {
+// makeComment makes comment token of #[ ... ]# block comment or comment until the end of line,
+// comments starting with ## at the start of line are doc comments
+func (l *JLexer) makeComment(isLineStart bool) (*token.JToken, bool, error) {
+	if l.isNextChar('[') {
+		return l.makeBlockComment()
+	}
+
+	startPos := l.Pos.Copy()
 	advanceAble := l.advance()
 
 	for advanceAble && l.getCurrentChar() != '\n' {
 		advanceAble = l.advance()
 	}
 
-	return advanceAble
+	comment := string(l.Text[startPos.Index:l.Pos.Index])
+	if isLineStart && strings.HasPrefix(comment, "##") {
+		docLine := strings.TrimPrefix(strings.TrimPrefix(comment, "##"), " ")
+		if doc, ok := l.docs[startPos.Ln-1]; ok {
+			delete(l.docs, startPos.Ln-1)
+			docLine = doc + "\n" + docLine
+		}
+
+		l.docs[startPos.Ln] = strings.TrimRight(docLine, " \t\r")
+	}
+
+	return token.NewJToken(token.COMMENT, comment, startPos, l.Pos), advanceAble, nil
+}
+
+// makeBlockComment makes comment token of #[ ... ]#, block comments can be nested
+func (l *JLexer) makeBlockComment() (*token.JToken, bool, error) {
+	startPos := l.Pos.Copy()
+	depth := 0
+
+	for {
+		switch {
+		case l.getCurrentChar() == '#' && l.isNextChar('['):
+			depth++
+			l.advance()
+		case l.getCurrentChar() == ']' && l.isNextChar('#'):
+			depth--
+			l.advance()
+		}
+
+		advanceAble := l.advance()
+		if depth == 0 {
+			comment := string(l.Text[startPos.Index:l.Pos.Index])
+
+			return token.NewJToken(token.COMMENT, comment, startPos, l.Pos), advanceAble, nil
+		}
+
+		if !advanceAble {
+			return nil, false, errors.Wrap(&common.JInvalidSyntaxError{
+				JError: &common.JError{
+					StartPos: startPos,
+					EndPos:   startPos.Copy().Advance(l.Text),
+				},
+				Details: "Expected ']#' to close block comment",
+			}, "failed to make block comment")
+		}
+	}
+}
+
+// attachDocs sets doc of the first token of each line which follows doc comments
+func (l *JLexer) attachDocs(tokens []*token.JToken) {
+	lineStart := true
+
+	for _, tok := range tokens {
+		switch tok.Type {
+		case token.COMMENT, token.EOF:
+			continue
+		case token.NEWLINE:
+			lineStart = true
+
+			continue
+		}
+
+		if doc, ok := l.docs[tok.StartPos.Ln-1]; ok && lineStart {
+			tok.Doc = doc
+		}
+
+		lineStart = false
+	}
+}
+
+// isLineStart reports whether the next token starts a line, comment tokens are ignored
+func isLineStart(tokens []*token.JToken) bool {
+	for index := len(tokens) - 1; index >= 0; index-- {
+		if tokens[index].Type != token.COMMENT {
+			return tokens[index].Type == token.NEWLINE
+		}
+	}
+
+	return true
+}
+
+func (l *JLexer) isNextQuote() bool {
+	return l.isNextChar('"') || l.isNextChar('\'')
+}
+
+func (l *JLexer) isNextChar(char byte) bool {
+	if l.Pos.Index+1 >= len(l.Text) {
+		return false
+	}
+
+	return l.Text[l.Pos.Index+1] == char
 }
 
 func (l *JLexer) advance() bool {
@@ -362,6 +607,10 @@ func isDigit(char byte) bool {
 	return '0' <= char && char <= '9'
 }
 
+func isHexDigit(char byte) bool {
+	return isDigit(char) || ('a' <= char && char <= 'f') || ('A' <= char && char <= 'F')
+}
+
 func isLetters(char byte) bool {
 	return ('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || char == '_'
 }
@@ -57,6 +57,135 @@ func TestJLexer_MakeTokens(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "bytes",
+			text: `b"\x00\x7fa" + b'\n' + b`,
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens)
+
+				resStr := []string{
+					"BYTES:\x00\x7fa", "PLUS", "BYTES:\n", "PLUS", "IDENTIFIER:b", "EOF",
+				}
+				require.Len(t, tokens, len(resStr))
+				for index, tok := range tokens {
+					require.Equal(t, resStr[index], tok.String())
+				}
+			},
+		},
+		{
+			name: "range",
+			text: "1..10 + 1.5..x",
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens)
+
+				resStr := []string{
+					"INT:1", "DOTDOT", "INT:10", "PLUS", "FLOAT:1.5", "DOTDOT", "IDENTIFIER:x", "EOF",
+				}
+				require.Len(t, tokens, len(resStr))
+				for index, tok := range tokens {
+					require.Equal(t, resStr[index], tok.String())
+				}
+			},
+		},
+		{
+			name: "null-safe operators",
+			text: "a?.b?[c] ?? d.e",
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens)
+
+				resStr := []string{
+					"IDENTIFIER:a", "QDOT", "IDENTIFIER:b", "QLSQUARE", "IDENTIFIER:c", "RSQUARE",
+					"QQ", "IDENTIFIER:d", "DOT", "IDENTIFIER:e", "EOF",
+				}
+				require.Len(t, tokens, len(resStr))
+				for index, tok := range tokens {
+					require.Equal(t, resStr[index], tok.String())
+				}
+			},
+		},
+		{
+			name: "pipe",
+			text: "x |> f",
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens)
+
+				resStr := []string{"IDENTIFIER:x", "PIPE", "IDENTIFIER:f", "EOF"}
+				require.Len(t, tokens, len(resStr))
+				for index, tok := range tokens {
+					require.Equal(t, resStr[index], tok.String())
+				}
+			},
+		},
+		{
+			name: "doc comments",
+			text: "## first\n##  second\nfun f() -> 1 ## not doc\n\n## detached\n\nx",
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens)
+
+				require.Equal(t, "KEYWORD:fun", tokens[2].String())
+				require.Equal(t, "first\n second", tokens[2].Doc)
+				for _, tok := range tokens[3:] {
+					require.Empty(t, tok.Doc)
+				}
+			},
+		},
+		{
+			name: "block comments",
+			text: "1 #[ a #[ nested ]#\n b ]# + 2 #[]#",
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens)
+
+				resStr := []string{"INT:1", "PLUS", "INT:2", "EOF"}
+				require.Len(t, tokens, len(resStr))
+				for index, tok := range tokens {
+					require.Equal(t, resStr[index], tok.String())
+				}
+			},
+		},
+		{
+			name: "unterminated block comment",
+			text: "1 #[ a #[ b ]#",
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "Expected ']#' to close block comment")
+				require.Empty(t, tokens)
+			},
+		},
+		{
+			name: "single question mark",
+			text: "a ? b",
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+				require.Empty(t, tokens)
+			},
+		},
+		{
+			name: "invalid hex escape of bytes",
+			text: `b"\x0g"`,
+			checkResult: func(t *testing.T, tokens []*token.JToken, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "Expected two hex digits")
+				require.Empty(t, tokens)
+			},
+		},
 		{
 			name: "illegal character &",
 			text: "1&",
@@ -90,3 +219,29 @@ func TestJLexer_MakeTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestJLexer_KeepComments(t *testing.T) {
+	t.Parallel()
+
+	tokens, err := lexer.NewJLexer("stdin", "## doc\nfun f() -> 1 # line\n#[ a\n#[ b ]# ]#x").KeepComments().MakeTokens()
+	require.NoError(t, err)
+
+	resStr := []string{
+		"COMMENT:## doc", "NEWLINE", "KEYWORD:fun", "IDENTIFIER:f", "LPAREN", "RPAREN", "ARROW", "INT:1",
+		"COMMENT:# line", "NEWLINE", "COMMENT:#[ a\n#[ b ]# ]#", "IDENTIFIER:x", "EOF",
+	}
+	require.Len(t, tokens, len(resStr))
+	for index, tok := range tokens {
+		require.Equal(t, resStr[index], tok.String())
+	}
+
+	require.Equal(t, "doc", tokens[2].Doc)
+
+	lineComment, blockComment := tokens[8], tokens[10]
+	require.Equal(t, int64(1), lineComment.StartPos.Ln)
+	require.Equal(t, 13, lineComment.StartPos.Col)
+	require.Equal(t, 19, lineComment.EndPos.Col)
+	require.Equal(t, int64(2), blockComment.StartPos.Ln)
+	require.Equal(t, int64(3), blockComment.EndPos.Ln)
+	require.Equal(t, 10, blockComment.EndPos.Col)
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/IfanTsai/jirachi/common"
@@ -15,24 +16,56 @@ const (
 	Base JNodeType = iota
 	Number
 	String
+	Bytes
 	List
 	Map
+	Tuple
+	ListComp
+	MapComp
 	VarAssign
 	VarIndexAssign
+	VarUnpackAssign
+	VarDeclare
+	ScopeDeclare
 	VarAccess
 	BinOp
 	UnaryOp
 	IfExpr
 	ForExpr
+	ForInExpr
 	WhileExpr
 	FuncDefExpr
 	CallExpr
+	PipeExpr
 	IndexExpr
+	MemberAccess
+	SliceExpr
 	ReturnExpr
 	ContinueExpr
 	BreakExpr
+	DeferExpr
+	EnumDef
+	TraitDef
+	ImplDef
+	MacroDef
+	Quote
+	Unquote
+	Shell
 )
 
+var nodeTypeNames = []string{
+	"base", "number", "string", "bytes", "list", "map", "tuple", "list_comp", "map_comp",
+	"assign", "index_assign", "unpack_assign", "declare", "scope_declare", "var", "bin_op", "unary_op",
+	"if", "for", "for_in", "while", "fun", "call", "pipe", "index", "member", "slice",
+	"return", "continue", "break", "defer", "enum", "trait", "impl", "macro", "quote", "unquote",
+	"shell",
+}
+
+// String returns name of node type, which is the kind of ast value in scripts
+func (t JNodeType) String() string {
+	return nodeTypeNames[t]
+}
+
 // JNode is general node interface of AST
 type JNode interface {
 	fmt.Stringer
@@ -91,6 +124,15 @@ func (s *JStringNode) Type() JNodeType {
 	return String
 }
 
+// JBytesNode is bytes node structure of AST
+type JBytesNode struct {
+	*JBaseNode
+}
+
+func (b *JBytesNode) Type() JNodeType {
+	return Bytes
+}
+
 // JListNode is list node structure of AST
 type JListNode struct {
 	*JBaseNode
@@ -120,7 +162,7 @@ func (l *JListNode) String() string {
 // JMapNode is map node structure of AST
 type JMapNode struct {
 	*JBaseNode
-	ElementMap map[JNode]JNode
+	KeyValueNodes [][2]JNode // { key expression, value expression } in source order
 }
 
 func (m *JMapNode) Type() JNodeType {
@@ -130,27 +172,55 @@ func (m *JMapNode) Type() JNodeType {
 func (m *JMapNode) String() string {
 	strBuilder := strings.Builder{}
 	strBuilder.WriteByte('{')
-	firstKey := true
-	for key, value := range m.ElementMap {
-		if !firstKey {
+	for index, keyValueNode := range m.KeyValueNodes {
+		if index != 0 {
 			strBuilder.WriteString(", ")
 		}
 
-		firstKey = false
-		strBuilder.WriteString(key.String())
+		strBuilder.WriteString(keyValueNode[0].String())
 		strBuilder.WriteString(": ")
-		strBuilder.WriteString(value.String())
+		strBuilder.WriteString(keyValueNode[1].String())
 	}
 	strBuilder.WriteByte('}')
 
 	return strBuilder.String()
 }
 
+// JTupleNode is tuple node structure of AST
+type JTupleNode struct {
+	*JBaseNode
+	ElementNodes []JNode
+}
+
+func (t *JTupleNode) Type() JNodeType {
+	return Tuple
+}
+
+func (t *JTupleNode) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteByte('(')
+	for index, element := range t.ElementNodes {
+		if index != 0 {
+			strBuilder.WriteString(", ")
+		}
+
+		strBuilder.WriteString(element.String())
+	}
+
+	if len(t.ElementNodes) == 1 {
+		strBuilder.WriteByte(',')
+	}
+	strBuilder.WriteByte(')')
+
+	return strBuilder.String()
+}
+
 // JIndexExprNode is index expression node structure of AST
 type JIndexExprNode struct {
 	*JBaseNode
-	IndexNode JNode
-	IndexExpr JNode
+	IndexNode  JNode
+	IndexExpr  JNode
+	IsNullSafe bool // a?[b] is null if a is null
 }
 
 func (i *JIndexExprNode) Type() JNodeType {
@@ -158,9 +228,76 @@ func (i *JIndexExprNode) Type() JNodeType {
 }
 
 func (i *JIndexExprNode) String() string {
+	if i.IsNullSafe {
+		return i.IndexNode.String() + "?[" + i.IndexExpr.String() + "]"
+	}
+
 	return i.IndexNode.String() + "[" + i.IndexExpr.String() + "]"
 }
 
+// JPipeExprNode is pipeline node structure of AST, x |> f(a) is rewritten to CallNode f(x, a)
+type JPipeExprNode struct {
+	*JBaseNode
+	ValueNode JNode
+	CallNode  *JCallExprNode
+}
+
+func (n *JPipeExprNode) Type() JNodeType {
+	return PipeExpr
+}
+
+// String returns the rewritten call
+func (n *JPipeExprNode) String() string {
+	return n.CallNode.String()
+}
+
+// JMemberAccessNode is member access node structure of AST, a.b is the same as a["b"]
+type JMemberAccessNode struct {
+	*JBaseNode // JBaseNode.Token is member name token
+	Node       JNode
+	IsNullSafe bool // a?.b is null if a is null
+}
+
+func (n *JMemberAccessNode) Type() JNodeType {
+	return MemberAccess
+}
+
+func (n *JMemberAccessNode) String() string {
+	if n.IsNullSafe {
+		return n.Node.String() + "?." + n.Token.String()
+	}
+
+	return n.Node.String() + "." + n.Token.String()
+}
+
+// JSliceExprNode is slice expression node structure of AST, StartExpr and EndExpr may be nil
+type JSliceExprNode struct {
+	*JBaseNode
+	SliceNode JNode
+	StartExpr JNode
+	EndExpr   JNode
+}
+
+func (s *JSliceExprNode) Type() JNodeType {
+	return SliceExpr
+}
+
+func (s *JSliceExprNode) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(s.SliceNode.String())
+	strBuilder.WriteByte('[')
+	if s.StartExpr != nil {
+		strBuilder.WriteString(s.StartExpr.String())
+	}
+	strBuilder.WriteByte(':')
+	if s.EndExpr != nil {
+		strBuilder.WriteString(s.EndExpr.String())
+	}
+	strBuilder.WriteByte(']')
+
+	return strBuilder.String()
+}
+
 // JBinOpNode is binary operation node structure of AST
 type JBinOpNode struct {
 	*JBaseNode
@@ -193,7 +330,8 @@ func (n *JUnaryOpNode) String() string {
 // JVarAssignNode is variable assign node structure of AST
 type JVarAssignNode struct {
 	*JBaseNode
-	Node JNode
+	Node      JNode
+	TypeToken *token.JToken // type annotation, may be nil
 }
 
 func (n *JVarAssignNode) Type() JNodeType {
@@ -201,7 +339,52 @@ func (n *JVarAssignNode) Type() JNodeType {
 }
 
 func (n *JVarAssignNode) String() string {
-	return "(" + n.Token.String() + " = " + n.Node.String() + ")"
+	return "(" + n.Token.String() + typeAnnotationString(n.TypeToken) + " = " + n.Node.String() + ")"
+}
+
+// typeAnnotationString returns ": type" of type annotation, empty string if it is nil
+func typeAnnotationString(typeToken *token.JToken) string {
+	if typeToken == nil {
+		return ""
+	}
+
+	return ": " + typeToken.String()
+}
+
+// JVarDeclareNode is let or const declaration node structure of AST, JBaseNode.Token is let or const keyword
+type JVarDeclareNode struct {
+	*JBaseNode
+	VarToken  *token.JToken
+	Node      JNode
+	TypeToken *token.JToken // type annotation, may be nil
+}
+
+func (n *JVarDeclareNode) Type() JNodeType {
+	return VarDeclare
+}
+
+func (n *JVarDeclareNode) String() string {
+	return "(" + n.Token.String() + " " + n.VarToken.String() + typeAnnotationString(n.TypeToken) + " = " +
+		n.Node.String() + ")"
+}
+
+// JScopeDeclareNode is global or nonlocal declaration node structure of AST, JBaseNode.Token is the keyword
+type JScopeDeclareNode struct {
+	*JBaseNode
+	VarTokens []*token.JToken
+}
+
+func (n *JScopeDeclareNode) Type() JNodeType {
+	return ScopeDeclare
+}
+
+func (n *JScopeDeclareNode) String() string {
+	varNames := make([]string, len(n.VarTokens))
+	for index, varToken := range n.VarTokens {
+		varNames[index] = varToken.String()
+	}
+
+	return n.Token.String() + " " + strings.Join(varNames, ", ")
 }
 
 // JVarIndexAssignNode is variable index assign node structure of AST
@@ -218,6 +401,34 @@ func (n *JVarIndexAssignNode) String() string {
 	return "(" + n.IndexExprNode.String() + " = " + n.Node.String() + ")"
 }
 
+// JVarUnpackAssignNode is variable unpack assign node structure of AST, eg. a, b = (1, 2)
+type JVarUnpackAssignNode struct {
+	*JBaseNode
+	VarTokens []*token.JToken
+	Node      JNode
+}
+
+func (n *JVarUnpackAssignNode) Type() JNodeType {
+	return VarUnpackAssign
+}
+
+func (n *JVarUnpackAssignNode) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteByte('(')
+	for index, varToken := range n.VarTokens {
+		if index != 0 {
+			strBuilder.WriteString(", ")
+		}
+
+		strBuilder.WriteString(varToken.String())
+	}
+	strBuilder.WriteString(" = ")
+	strBuilder.WriteString(n.Node.String())
+	strBuilder.WriteByte(')')
+
+	return strBuilder.String()
+}
+
 // JVarAccessNode is variable access node structure of AST
 type JVarAccessNode struct {
 	*JBaseNode
@@ -231,6 +442,86 @@ func (n *JVarAccessNode) String() string {
 	return n.Token.String()
 }
 
+// JCompClause is a 'for ... in ... if ...' clause of comprehension
+type JCompClause struct {
+	VarTokens      []*token.JToken
+	IterableNode   JNode
+	ConditionNodes []JNode
+}
+
+func (c *JCompClause) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString("for ")
+	for index, varToken := range c.VarTokens {
+		if index != 0 {
+			strBuilder.WriteString(", ")
+		}
+
+		strBuilder.WriteString(varToken.String())
+	}
+
+	strBuilder.WriteString(" in ")
+	strBuilder.WriteString(c.IterableNode.String())
+
+	for _, conditionNode := range c.ConditionNodes {
+		strBuilder.WriteString(" if ")
+		strBuilder.WriteString(conditionNode.String())
+	}
+
+	return strBuilder.String()
+}
+
+// JListCompNode is list comprehension node structure of AST
+type JListCompNode struct {
+	*JBaseNode
+	ElementNode JNode
+	Clauses     []*JCompClause
+}
+
+func (n *JListCompNode) Type() JNodeType {
+	return ListComp
+}
+
+func (n *JListCompNode) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString("[")
+	strBuilder.WriteString(n.ElementNode.String())
+	for _, clause := range n.Clauses {
+		strBuilder.WriteString(" ")
+		strBuilder.WriteString(clause.String())
+	}
+	strBuilder.WriteString("]")
+
+	return strBuilder.String()
+}
+
+// JMapCompNode is map comprehension node structure of AST
+type JMapCompNode struct {
+	*JBaseNode
+	KeyNode   JNode
+	ValueNode JNode
+	Clauses   []*JCompClause
+}
+
+func (n *JMapCompNode) Type() JNodeType {
+	return MapComp
+}
+
+func (n *JMapCompNode) String() string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString("{")
+	strBuilder.WriteString(n.KeyNode.String())
+	strBuilder.WriteString(": ")
+	strBuilder.WriteString(n.ValueNode.String())
+	for _, clause := range n.Clauses {
+		strBuilder.WriteString(" ")
+		strBuilder.WriteString(clause.String())
+	}
+	strBuilder.WriteString("}")
+
+	return strBuilder.String()
+}
+
 // JIfExprNode is if expression node structure of AST
 type JIfExprNode struct {
 	*JBaseNode
@@ -272,6 +563,8 @@ type JForExprNode struct {
 	EndValueNode      JNode
 	StepValueNode     JNode
 	BodyNode          JNode
+	ElseNode          JNode // runs if the loop isn't broken, may be nil
+	LabelToken        *token.JToken
 	IsBlockStatements bool
 }
 
@@ -279,11 +572,32 @@ func (n *JForExprNode) Type() JNodeType {
 	return ForExpr
 }
 
+// JForInExprNode is for ... in expression node structure of AST
+type JForInExprNode struct {
+	*JBaseNode        // JBaseNode.Token is variable name token
+	IterableNode      JNode
+	BodyNode          JNode
+	ElseNode          JNode // runs if the loop isn't broken, may be nil
+	LabelToken        *token.JToken
+	IsBlockStatements bool
+}
+
+func (n *JForInExprNode) Type() JNodeType {
+	return ForInExpr
+}
+
+func (n *JForInExprNode) String() string {
+	return loopString(n.LabelToken,
+		"for ("+n.Token.String()+" in "+n.IterableNode.String()+") {"+n.BodyNode.String()+"}", n.ElseNode)
+}
+
 // JWhileExprNode is while expression node structure of AST
 type JWhileExprNode struct {
 	*JBaseNode
 	ConditionNode     JNode
 	BodyNode          JNode
+	ElseNode          JNode // runs if the loop isn't broken, may be nil
+	LabelToken        *token.JToken
 	IsBlockStatements bool
 }
 
@@ -299,14 +613,31 @@ func (n *JWhileExprNode) String() string {
 	strBuilder.WriteString(n.BodyNode.String())
 	strBuilder.WriteString("}")
 
-	return strBuilder.String()
+	return loopString(n.LabelToken, strBuilder.String(), n.ElseNode)
+}
+
+// loopString adds label and else clause to string of loop
+func loopString(labelToken *token.JToken, loopStr string, elseNode JNode) string {
+	if labelToken != nil {
+		loopStr = labelToken.String() + ": " + loopStr
+	}
+
+	if elseNode != nil {
+		loopStr += " else {" + elseNode.String() + "}"
+	}
+
+	return loopStr
 }
 
 // JFuncDefNode is function definition node structure of AST
 type JFuncDefNode struct {
-	*JBaseNode // JBaseNode.Token is function name token
-	ArgTokens  []*token.JToken
-	BodyNode   JNode
+	*JBaseNode      // JBaseNode.Token is function name token
+	ArgTokens       []*token.JToken
+	ArgTypeTokens   []*token.JToken // type annotations of args, nil if the arg isn't annotated
+	ReturnTypeToken *token.JToken   // may be nil
+	BodyNode        JNode
+	DecoratorNodes  []JNode // expressions of @decorator lines in source order
+	Doc             string  // leading string literal of block body or ## doc comments before the definition
 }
 
 func (n *JFuncDefNode) Type() JNodeType {
@@ -320,15 +651,29 @@ func (n *JFuncDefNode) String() string {
 		strBuilder.WriteString(n.Token.String())
 	}
 
+	if len(n.DecoratorNodes) > 0 {
+		decorators := make([]string, len(n.DecoratorNodes))
+		for index, decoratorNode := range n.DecoratorNodes {
+			decorators[index] = "@" + decoratorNode.String()
+		}
+
+		strBuilder.WriteString(" <decorators>(" + strings.Join(decorators, " ") + ")")
+	}
+
 	strBuilder.WriteString(" <args>(")
 	for index, argToken := range n.ArgTokens {
 		if index != 0 {
 			strBuilder.WriteByte(' ')
 		}
 		strBuilder.WriteString(argToken.String())
+		strBuilder.WriteString(typeAnnotationString(n.ArgTypeTokens[index]))
 	}
 	strBuilder.WriteString(") ")
 
+	if n.ReturnTypeToken != nil {
+		strBuilder.WriteString("-> " + n.ReturnTypeToken.String() + " ")
+	}
+
 	strBuilder.WriteString("<body>" + n.BodyNode.String())
 
 	strBuilder.WriteByte(')')
@@ -382,6 +727,7 @@ func (n *JReturnNode) String() string {
 
 type JContinueNode struct {
 	*JBaseNode
+	LabelToken *token.JToken // may be nil
 }
 
 func (n *JContinueNode) Type() JNodeType {
@@ -389,11 +735,16 @@ func (n *JContinueNode) Type() JNodeType {
 }
 
 func (n *JContinueNode) String() string {
+	if n.LabelToken != nil {
+		return n.Token.String() + " " + n.LabelToken.String()
+	}
+
 	return n.Token.String()
 }
 
 type JBreakNode struct {
 	*JBaseNode
+	LabelToken *token.JToken // may be nil
 }
 
 func (n *JBreakNode) Type() JNodeType {
@@ -401,5 +752,173 @@ func (n *JBreakNode) Type() JNodeType {
 }
 
 func (n *JBreakNode) String() string {
+	if n.LabelToken != nil {
+		return n.Token.String() + " " + n.LabelToken.String()
+	}
+
 	return n.Token.String()
 }
+
+// JDeferNode is defer statement node structure of AST, DeferNode is visited when the function returns
+type JDeferNode struct {
+	*JBaseNode
+	DeferNode JNode
+}
+
+func (n *JDeferNode) Type() JNodeType {
+	return DeferExpr
+}
+
+func (n *JDeferNode) String() string {
+	return n.Token.String() + " " + n.DeferNode.String()
+}
+
+// JEnumDefNode is enum declaration node structure of AST, JBaseNode.Token is enum name token.
+// ValueNodes[i] is the explicit value of MemberTokens[i], nil if it isn't given
+type JEnumDefNode struct {
+	*JBaseNode
+	MemberTokens []*token.JToken
+	ValueNodes   []JNode
+}
+
+func (n *JEnumDefNode) Type() JNodeType {
+	return EnumDef
+}
+
+func (n *JEnumDefNode) String() string {
+	members := make([]string, len(n.MemberTokens))
+	for index, memberToken := range n.MemberTokens {
+		members[index] = memberToken.String()
+		if n.ValueNodes[index] != nil {
+			members[index] += " = " + n.ValueNodes[index].String()
+		}
+	}
+
+	return "(<ENUM> " + n.Token.String() + " {" + strings.Join(members, ", ") + "})"
+}
+
+// JTraitDefNode is trait declaration node structure of AST, JBaseNode.Token is trait name token.
+// Methods of trait have no body, MethodArgTokens[i] are args of MethodTokens[i] and start with self
+type JTraitDefNode struct {
+	*JBaseNode
+	MethodTokens    []*token.JToken
+	MethodArgTokens [][]*token.JToken
+}
+
+func (n *JTraitDefNode) Type() JNodeType {
+	return TraitDef
+}
+
+func (n *JTraitDefNode) String() string {
+	methods := make([]string, len(n.MethodTokens))
+	for index, methodToken := range n.MethodTokens {
+		args := make([]string, len(n.MethodArgTokens[index]))
+		for argIndex, argToken := range n.MethodArgTokens[index] {
+			args[argIndex] = argToken.String()
+		}
+
+		methods[index] = methodToken.String() + "(" + strings.Join(args, ", ") + ")"
+	}
+
+	return "(<TRAIT> " + n.Token.String() + " {" + strings.Join(methods, ", ") + "})"
+}
+
+// JImplDefNode is impl block node structure of AST, JBaseNode.Token is trait name token,
+// TargetToken is name of enum or type which implements the trait
+type JImplDefNode struct {
+	*JBaseNode
+	TargetToken *token.JToken
+	MethodNodes []*JFuncDefNode
+}
+
+func (n *JImplDefNode) Type() JNodeType {
+	return ImplDef
+}
+
+func (n *JImplDefNode) String() string {
+	methods := make([]string, len(n.MethodNodes))
+	for index, methodNode := range n.MethodNodes {
+		methods[index] = methodNode.String()
+	}
+
+	return "(<IMPL> " + n.Token.String() + " for " + n.TargetToken.String() + " {" + strings.Join(methods, ", ") + "})"
+}
+
+// JMacroDefNode is macro definition node structure of AST, JBaseNode.Token is macro name token.
+// The macro is defined like a function, but it is called while expanding macros before the program runs
+type JMacroDefNode struct {
+	*JBaseNode
+	FuncDefNode *JFuncDefNode
+}
+
+func (n *JMacroDefNode) Type() JNodeType {
+	return MacroDef
+}
+
+func (n *JMacroDefNode) String() string {
+	return "(<MACRO> " + strings.TrimPrefix(n.FuncDefNode.String(), "(<FUNCTION> ")
+}
+
+// JQuoteNode is quote expression node structure of AST, Node is the quoted expression or block
+// which is not evaluated but becomes ast value
+type JQuoteNode struct {
+	*JBaseNode
+	Node JNode
+}
+
+func (n *JQuoteNode) Type() JNodeType {
+	return Quote
+}
+
+func (n *JQuoteNode) String() string {
+	return "(<QUOTE> " + n.Node.String() + ")"
+}
+
+// JUnquoteNode is unquote expression node structure of AST, it is only allowed inside quote,
+// where it is replaced by ast of the value of Node
+type JUnquoteNode struct {
+	*JBaseNode
+	Node JNode
+}
+
+func (n *JUnquoteNode) Type() JNodeType {
+	return Unquote
+}
+
+func (n *JUnquoteNode) String() string {
+	return "(<UNQUOTE> " + n.Node.String() + ")"
+}
+
+// JShellNode is shell literal node structure of AST, eg. @"ls {dir}". Fragments are the literal text around
+// ValueNodes, whose values are shell-quoted unless they are raw, eg. @"ls {!flags}"
+type JShellNode struct {
+	*JBaseNode
+	CallNode     JNode    // @ which runs the command
+	Fragments    []string // len(Fragments) is len(ValueNodes) + 1
+	ValueNodes   []JNode
+	RawValues    []bool
+	DoubleQuoted []bool // value is inside double quotes of shell, where single quotes don't quote
+}
+
+func (n *JShellNode) Type() JNodeType {
+	return Shell
+}
+
+func (n *JShellNode) String() string {
+	var builder strings.Builder
+
+	builder.WriteString("(<SHELL> " + strconv.Quote(n.Fragments[0]))
+
+	for index, valueNode := range n.ValueNodes {
+		raw := ""
+		if n.RawValues[index] {
+			raw = "!"
+		}
+
+		builder.WriteString(" {" + raw + valueNode.String() + "} " + strconv.Quote(n.Fragments[index+1]))
+	}
+
+	builder.WriteString(")")
+
+	return builder.String()
+}
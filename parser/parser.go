@@ -2,10 +2,12 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/IfanTsai/go-lib/set"
 
 	"github.com/IfanTsai/jirachi/common"
+	"github.com/IfanTsai/jirachi/lexer"
 	"github.com/IfanTsai/jirachi/token"
 	"github.com/pkg/errors"
 )
@@ -16,6 +18,7 @@ type JParser struct {
 	TokenIndex   int
 	Tokens       []*token.JToken
 	CurrentToken *token.JToken
+	quoteDepth   int // number of quote expressions the current token is inside
 }
 
 func NewJParser(tokens []*token.JToken, tokenIndex int) *JParser {
@@ -43,6 +46,25 @@ func (p *JParser) Parse() (JNode, error) {
 	return ast, nil
 }
 
+// ParseExpr parses tokens of a single expression, new lines around it are allowed
+func (p *JParser) ParseExpr() (JNode, error) {
+	p.advance()
+	p.skipNewlines()
+
+	ast, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipNewlines()
+
+	if p.CurrentToken.Type != token.EOF {
+		return nil, p.createInvalidSyntaxError("end of expression", "expression")
+	}
+
+	return ast, nil
+}
+
 func (p *JParser) advance() {
 	p.TokenIndex++
 	if p.TokenIndex < len(p.Tokens) {
@@ -68,10 +90,24 @@ func (p *JParser) indexExpr(atomNode JNode) (JNode, error) {
 	startPos := p.CurrentToken.StartPos
 
 	p.advance()
+	p.skipNewlines()
 
-	expr, err := p.expr()
-	if err != nil {
-		return nil, err
+	var (
+		expr JNode
+		err  error
+	)
+
+	if p.CurrentToken.Type != token.COLON {
+		expr, err = p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipNewlines()
+	}
+
+	if p.CurrentToken.Type == token.COLON {
+		return p.sliceExpr(atomNode, startPos, expr)
 	}
 
 	if p.CurrentToken.Type != token.RSQUARE {
@@ -113,35 +149,124 @@ func (p *JParser) indexExpr(atomNode JNode) (JNode, error) {
 	return indexExprNode, nil
 }
 
-func (p *JParser) listExpr() (JNode, error) {
+// parenExpr parses a parenthesized expression or a tuple, eg. (1), (), (1,) and (1, 2)
+func (p *JParser) parenExpr() (JNode, error) {
 	startPos := p.CurrentToken.StartPos
 
 	p.advance()
+	p.skipNewlines()
 
 	var elementNodes []JNode
+	isTuple := p.CurrentToken.Type == token.RPAREN
+
+	for p.CurrentToken.Type != token.RPAREN {
+		expr, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		elementNodes = append(elementNodes, expr)
+		p.skipNewlines()
+
+		if p.CurrentToken.Type != token.COMMA {
+			break
+		}
+
+		isTuple = true
+
+		p.advance()
+		p.skipNewlines()
+	}
+
+	if p.CurrentToken.Type != token.RPAREN {
+		return nil, p.createInvalidSyntaxError("')'", "LPAREN")
+	}
+
+	p.advance()
+
+	if !isTuple {
+		return elementNodes[0], nil
+	}
+
+	return &JTupleNode{
+		JBaseNode: &JBaseNode{
+			StartPos: startPos,
+			EndPos:   p.CurrentToken.EndPos.Copy().Back(nil),
+		},
+		ElementNodes: elementNodes,
+	}, nil
+}
+
+func (p *JParser) sliceExpr(atomNode JNode, startPos *common.JPosition, startExpr JNode) (JNode, error) {
+	p.advance()
+	p.skipNewlines()
+
+	var (
+		endExpr JNode
+		err     error
+	)
+
 	if p.CurrentToken.Type != token.RSQUARE {
-		isFirstElement := true
-		for isFirstElement || p.CurrentToken.Type == token.COMMA {
-			if !isFirstElement {
-				p.advance()
-			} else {
-				isFirstElement = false
-			}
+		endExpr, err = p.expr()
+		if err != nil {
+			return nil, err
+		}
 
-			expr, err := p.expr()
-			if err != nil {
-				return nil, p.createInvalidSyntaxError(
-					"Expected ']', 'if', 'for', 'while', 'fun', number, identifier, '+', '-', '(', '[', or 'not'",
-					"list expression",
-				)
-			}
+		p.skipNewlines()
+	}
+
+	if p.CurrentToken.Type != token.RSQUARE {
+		return nil, p.createInvalidSyntaxError("']'", "slice expression")
+	}
+
+	p.advance()
+
+	return &JSliceExprNode{
+		JBaseNode: &JBaseNode{
+			StartPos: startPos,
+			EndPos:   p.CurrentToken.EndPos.Copy().Back(nil),
+		},
+		SliceNode: atomNode,
+		StartExpr: startExpr,
+		EndExpr:   endExpr,
+	}, nil
+}
+
+func (p *JParser) listExpr() (JNode, error) {
+	startPos := p.CurrentToken.StartPos
+
+	p.advance()
+	p.skipNewlines()
+
+	// newlines are ignored inside brackets, and the last element can be followed by comma
+	var elementNodes []JNode
+	for p.CurrentToken.Type != token.RSQUARE {
+		expr, err := p.expr()
+		if err != nil {
+			return nil, p.createInvalidSyntaxError(
+				"Expected ']', 'if', 'for', 'while', 'fun', number, identifier, '+', '-', '(', '[', or 'not'",
+				"list expression",
+			)
+		}
 
-			elementNodes = append(elementNodes, expr)
+		p.skipNewlines()
+
+		if len(elementNodes) == 0 && p.CurrentToken.Match(token.KEYWORD, token.FOR) {
+			return p.listCompExpr(startPos, expr)
 		}
 
-		if p.CurrentToken.Type != token.RSQUARE {
-			return nil, p.createInvalidSyntaxError("',' or ']'", "list expression")
+		elementNodes = append(elementNodes, expr)
+
+		if p.CurrentToken.Type != token.COMMA {
+			break
 		}
+
+		p.advance()
+		p.skipNewlines()
+	}
+
+	if p.CurrentToken.Type != token.RSQUARE {
+		return nil, p.createInvalidSyntaxError("',' or ']'", "list expression")
 	}
 
 	p.advance()
@@ -158,42 +283,51 @@ func (p *JParser) mapExpr() (JNode, error) {
 	startPos := p.CurrentToken.StartPos
 
 	p.advance()
+	p.skipNewlines()
 
-	elementMap := make(map[JNode]JNode)
-	if p.CurrentToken.Type != token.RBRACE {
-		isFirstElement := true
-		for isFirstElement || p.CurrentToken.Type == token.COMMA {
-			if !isFirstElement {
-				p.advance()
-			} else {
-				isFirstElement = false
-			}
+	// newlines are ignored inside braces, and the last pair can be followed by comma
+	var keyValueNodes [][2]JNode
+	for p.CurrentToken.Type != token.RBRACE {
+		KeyExpr, err := p.expr()
+		if err != nil {
+			return nil, p.createInvalidSyntaxError(
+				"Expected '}', 'if', 'for', 'while', 'fun', number, identifier, '+', '-', '(', '[', or 'not'",
+				"map expression",
+			)
+		}
 
-			KeyExpr, err := p.expr()
-			if err != nil {
-				return nil, p.createInvalidSyntaxError(
-					"Expected '}', 'if', 'for', 'while', 'fun', number, identifier, '+', '-', '(', '[', or 'not'",
-					"map expression",
-				)
-			}
+		p.skipNewlines()
 
-			if p.CurrentToken.Type != token.COLON {
-				return nil, p.createInvalidSyntaxError("':'", "map expression")
-			}
+		if p.CurrentToken.Type != token.COLON {
+			return nil, p.createInvalidSyntaxError("':'", "map expression")
+		}
 
-			p.advance()
+		p.advance()
+		p.skipNewlines()
 
-			valueExpr, err := p.expr()
-			if err != nil {
-				return nil, err
-			}
+		valueExpr, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipNewlines()
 
-			elementMap[KeyExpr] = valueExpr
+		if len(keyValueNodes) == 0 && p.CurrentToken.Match(token.KEYWORD, token.FOR) {
+			return p.mapCompExpr(startPos, KeyExpr, valueExpr)
 		}
 
-		if p.CurrentToken.Type != token.RBRACE {
-			return nil, p.createInvalidSyntaxError("',' or '}'", "map expression")
+		keyValueNodes = append(keyValueNodes, [2]JNode{KeyExpr, valueExpr})
+
+		if p.CurrentToken.Type != token.COMMA {
+			break
 		}
+
+		p.advance()
+		p.skipNewlines()
+	}
+
+	if p.CurrentToken.Type != token.RBRACE {
+		return nil, p.createInvalidSyntaxError("',' or '}'", "map expression")
 	}
 
 	p.advance()
@@ -202,50 +336,131 @@ func (p *JParser) mapExpr() (JNode, error) {
 			StartPos: startPos,
 			EndPos:   p.CurrentToken.EndPos.Copy().Back(nil),
 		},
-		ElementMap: elementMap,
+		KeyValueNodes: keyValueNodes,
 	}, nil
 }
 
-func (p *JParser) whileExpr() (JNode, error) {
-	if !p.CurrentToken.Match(token.KEYWORD, token.WHILE) {
-		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.WHILE), "while expression")
+// listCompExpr parses the rest of list comprehension after the element expression, eg. [x * 2 for x in xs if x > 1]
+func (p *JParser) listCompExpr(startPos *common.JPosition, elementExpr JNode) (JNode, error) {
+	clauses, err := p.compClauses(token.RSQUARE, "]", "list comprehension")
+	if err != nil {
+		return nil, err
 	}
 
 	p.advance()
 
-	conditionExpr, err := p.expr()
+	return &JListCompNode{
+		JBaseNode: &JBaseNode{
+			StartPos: startPos,
+			EndPos:   p.CurrentToken.EndPos.Copy().Back(nil),
+		},
+		ElementNode: elementExpr,
+		Clauses:     clauses,
+	}, nil
+}
+
+// mapCompExpr parses the rest of map comprehension after the key and value expressions, eg. {k: v for k, v in m}
+func (p *JParser) mapCompExpr(startPos *common.JPosition, keyExpr, valueExpr JNode) (JNode, error) {
+	clauses, err := p.compClauses(token.RBRACE, "}", "map comprehension")
 	if err != nil {
 		return nil, err
 	}
 
-	if !p.CurrentToken.Match(token.KEYWORD, token.THEN) {
-		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.THEN), "while expression")
-	}
-
 	p.advance()
 
-	var body JNode
-	isBlock := false
+	return &JMapCompNode{
+		JBaseNode: &JBaseNode{
+			StartPos: startPos,
+			EndPos:   p.CurrentToken.EndPos.Copy().Back(nil),
+		},
+		KeyNode:   keyExpr,
+		ValueNode: valueExpr,
+		Clauses:   clauses,
+	}, nil
+}
+
+// compClauses parses 'for' clauses of comprehension until the close token,
+// every clause is 'for' IDENTIFIER (',' IDENTIFIER)* 'in' expr ('if' expr)*
+func (p *JParser) compClauses(closeTokenType token.JTokenType, closeChar, parseType string) ([]*JCompClause, error) {
+	var clauses []*JCompClause
 
-	if p.CurrentToken.Type == token.NEWLINE {
-		isBlock = true
+	for p.CurrentToken.Match(token.KEYWORD, token.FOR) {
 		p.advance()
 
-		body, err = p.statements(true)
-		if err != nil {
-			return nil, err
+		clause := &JCompClause{}
+
+		for {
+			if p.CurrentToken.Type != token.IDENTIFIER {
+				return nil, p.createInvalidSyntaxError("identifier", parseType)
+			}
+
+			clause.VarTokens = append(clause.VarTokens, p.CurrentToken)
+			p.advance()
+
+			if p.CurrentToken.Type != token.COMMA {
+				break
+			}
+
+			p.advance()
 		}
 
-		if !p.CurrentToken.Match(token.KEYWORD, token.END) {
-			return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.END), "while expression")
+		if !p.CurrentToken.Match(token.KEYWORD, token.IN) {
+			return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.IN), parseType)
 		}
 
 		p.advance()
-	} else {
-		body, err = p.statement()
+
+		iterableExpr, err := p.expr()
 		if err != nil {
 			return nil, err
 		}
+
+		clause.IterableNode = iterableExpr
+		p.skipNewlines()
+
+		for p.CurrentToken.Match(token.KEYWORD, token.IF) {
+			p.advance()
+
+			conditionExpr, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+
+			clause.ConditionNodes = append(clause.ConditionNodes, conditionExpr)
+			p.skipNewlines()
+		}
+
+		clauses = append(clauses, clause)
+	}
+
+	if p.CurrentToken.Type != closeTokenType {
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s', '%s' or '%s'", token.FOR, token.IF, closeChar), parseType)
+	}
+
+	return clauses, nil
+}
+
+func (p *JParser) whileExpr() (JNode, error) {
+	if !p.CurrentToken.Match(token.KEYWORD, token.WHILE) {
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.WHILE), "while expression")
+	}
+
+	p.advance()
+
+	conditionExpr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.CurrentToken.Match(token.KEYWORD, token.THEN) {
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.THEN), "while expression")
+	}
+
+	p.advance()
+
+	body, elseBody, isBlock, err := p.loopBody("while expression")
+	if err != nil {
+		return nil, err
 	}
 
 	return &JWhileExprNode{
@@ -255,6 +470,7 @@ func (p *JParser) whileExpr() (JNode, error) {
 		},
 		ConditionNode:     conditionExpr,
 		BodyNode:          body,
+		ElseNode:          elseBody,
 		IsBlockStatements: isBlock,
 	}, nil
 }
@@ -274,8 +490,12 @@ func (p *JParser) forExpr() (JNode, error) {
 
 	p.advance()
 
+	if p.CurrentToken.Match(token.KEYWORD, token.IN) {
+		return p.forInExpr(varNameToken)
+	}
+
 	if p.CurrentToken.Type != token.EQ {
-		return nil, p.createInvalidSyntaxError("'='", "for expression")
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'=' or '%s'", token.IN), "for expression")
 	}
 
 	p.advance()
@@ -312,28 +532,9 @@ func (p *JParser) forExpr() (JNode, error) {
 
 	p.advance()
 
-	var body JNode
-	isBlock := false
-
-	if p.CurrentToken.Type == token.NEWLINE {
-		isBlock = true
-		p.advance()
-
-		body, err = p.statements(true)
-		if err != nil {
-			return nil, err
-		}
-
-		if !p.CurrentToken.Match(token.KEYWORD, token.END) {
-			return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.END), "for expression")
-		}
-
-		p.advance()
-	} else {
-		body, err = p.statement()
-		if err != nil {
-			return nil, err
-		}
+	body, elseBody, isBlock, err := p.loopBody("for expression")
+	if err != nil {
+		return nil, err
 	}
 
 	return &JForExprNode{
@@ -346,38 +547,116 @@ func (p *JParser) forExpr() (JNode, error) {
 		EndValueNode:      endExpr,
 		StepValueNode:     stepExpr,
 		BodyNode:          body,
+		ElseNode:          elseBody,
 		IsBlockStatements: isBlock,
 	}, nil
 }
 
-func (p *JParser) ifExpr() (JNode, error) {
-	cases, elseCase, err := p.parseIfExprCases(token.IF)
+// forInExpr parses the rest of for expression after variable name, eg. for x in iterable then ...
+func (p *JParser) forInExpr(varNameToken *token.JToken) (JNode, error) {
+	p.advance()
+
+	iterableExpr, err := p.expr()
 	if err != nil {
 		return nil, err
 	}
 
-	var endPos *common.JPosition
-	if elseCase != nil {
-		endPos = elseCase.GetEndPos()
-	} else {
-		endPos = cases[len(cases)-1][0].GetEndPos()
+	if !p.CurrentToken.Match(token.KEYWORD, token.THEN) {
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.THEN), "for expression")
 	}
 
-	return &JIfExprNode{
+	p.advance()
+
+	body, elseBody, isBlock, err := p.loopBody("for expression")
+	if err != nil {
+		return nil, err
+	}
+
+	return &JForInExprNode{
 		JBaseNode: &JBaseNode{
-			StartPos: cases[0][0].GetStartPos(),
-			EndPos:   endPos,
+			Token:    varNameToken,
+			StartPos: varNameToken.StartPos,
+			EndPos:   body.GetEndPos(),
 		},
-		CaseNodes:    cases,
-		ElseCaseNode: elseCase,
+		IterableNode:      iterableExpr,
+		BodyNode:          body,
+		ElseNode:          elseBody,
+		IsBlockStatements: isBlock,
 	}, nil
 }
 
-func (p *JParser) elifExpr() ([][2]JNode, JNode, error) {
-	return p.parseIfExprCases(token.ELIF)
-}
-
-func (p *JParser) elseExpr() (JNode, error) {
+// loopBody parses body of loop after 'then' and the optional 'else' clause, which runs if the loop isn't broken.
+// the body is a block ended with 'end' if it starts with a new line
+func (p *JParser) loopBody(parseType string) (body, elseBody JNode, isBlock bool, err error) {
+	if p.CurrentToken.Type != token.NEWLINE {
+		body, err = p.statement()
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		if p.CurrentToken.Match(token.KEYWORD, token.ELSE) {
+			p.advance()
+
+			if elseBody, err = p.statement(); err != nil {
+				return nil, nil, false, err
+			}
+		}
+
+		return body, elseBody, false, nil
+	}
+
+	p.advance()
+
+	body, err = p.statements(true)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if p.CurrentToken.Match(token.KEYWORD, token.ELSE) {
+		p.advance()
+
+		if elseBody, err = p.statements(true); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	if !p.CurrentToken.Match(token.KEYWORD, token.END) {
+		return nil, nil, false, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.END), parseType)
+	}
+
+	p.advance()
+
+	return body, elseBody, true, nil
+}
+
+func (p *JParser) ifExpr() (JNode, error) {
+	cases, elseCase, err := p.parseIfExprCases(token.IF)
+	if err != nil {
+		return nil, err
+	}
+
+	var endPos *common.JPosition
+	if elseCase != nil {
+		endPos = elseCase.GetEndPos()
+	} else {
+		endPos = cases[len(cases)-1][0].GetEndPos()
+	}
+
+	return &JIfExprNode{
+		JBaseNode: &JBaseNode{
+			StartPos: cases[0][0].GetStartPos(),
+			EndPos:   endPos,
+		},
+		CaseNodes:    cases,
+		ElseCaseNode: elseCase,
+	}, nil
+}
+
+func (p *JParser) elifExpr() ([][2]JNode, JNode, error) {
+	return p.parseIfExprCases(token.ELIF)
+}
+
+func (p *JParser) elseExpr() (JNode, error) {
 	var err error
 	var elseCase JNode
 
@@ -488,6 +767,7 @@ func (p *JParser) funcDef() (JNode, error) {
 		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.FUN), "function definition")
 	}
 
+	funToken := p.CurrentToken
 	p.advance()
 
 	var varNameToken *token.JToken
@@ -497,49 +777,44 @@ func (p *JParser) funcDef() (JNode, error) {
 		p.advance()
 	}
 
-	if p.CurrentToken.Type != token.LPAREN {
-		return nil, p.createInvalidSyntaxError("'('", "function definition")
+	funcDefNode, err := p.funcDefBody(funToken, varNameToken)
+	if err != nil {
+		return nil, err
 	}
 
-	p.advance()
-
-	var argTokens []*token.JToken
-	if p.CurrentToken.Type == token.IDENTIFIER {
-		argTokens = append(argTokens, p.CurrentToken)
-
-		p.advance()
-
-		for p.CurrentToken.Type == token.COMMA {
-			p.advance()
-
-			if p.CurrentToken.Type != token.IDENTIFIER {
-				return nil, p.createInvalidSyntaxError("identifier", "function definition")
-			}
-
-			argTokens = append(argTokens, p.CurrentToken)
-
-			p.advance()
-		}
-	}
+	return funcDefNode, nil
+}
 
-	if p.CurrentToken.Type != token.RPAREN {
-		return nil, p.createInvalidSyntaxError("')'", "function definition")
+// funcDefBody parses args, optional return type and body of function definition starting with startToken
+func (p *JParser) funcDefBody(startToken, varNameToken *token.JToken) (*JFuncDefNode, error) {
+	argTokens, argTypeTokens, err := p.funcArgs()
+	if err != nil {
+		return nil, err
 	}
 
-	p.advance()
-
 	var (
-		body JNode
-		err  error
+		body            JNode
+		returnTypeToken *token.JToken
+		endPos          *common.JPosition
+		doc             = startToken.Doc // ## doc comments before the definition
 	)
 
-	if p.CurrentToken.Type == token.ARROW {
+	// -> type followed by new line is return type annotation of function whose body is block
+	if p.isReturnTypeAnnotation() {
+		p.advance()
+		returnTypeToken = p.CurrentToken
+		p.advance()
+	}
+
+	if p.CurrentToken.Type == token.ARROW && returnTypeToken == nil {
 		p.advance()
 
 		body, err = p.expr()
 		if err != nil {
 			return nil, err
 		}
+
+		endPos = body.GetEndPos()
 	} else if p.CurrentToken.Type == token.NEWLINE {
 		p.advance()
 
@@ -548,10 +823,16 @@ func (p *JParser) funcDef() (JNode, error) {
 			return nil, err
 		}
 
+		// leading string literal of block body is preferred to doc comments
+		if bodyDoc, ok := leadingString(body); ok {
+			doc = bodyDoc
+		}
+
 		if !p.CurrentToken.Match(token.KEYWORD, token.END) {
 			return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.END), "function definition expression")
 		}
 
+		endPos = p.CurrentToken.EndPos
 		p.advance()
 	} else {
 		return nil, p.createInvalidSyntaxError("'->' or NEWLINE", "function definition expression")
@@ -559,13 +840,125 @@ func (p *JParser) funcDef() (JNode, error) {
 
 	return &JFuncDefNode{
 		JBaseNode: &JBaseNode{
-			Token: varNameToken,
+			Token:    varNameToken,
+			StartPos: startToken.StartPos,
+			EndPos:   endPos,
 		},
-		ArgTokens: argTokens,
-		BodyNode:  body,
+		ArgTokens:       argTokens,
+		ArgTypeTokens:   argTypeTokens,
+		ReturnTypeToken: returnTypeToken,
+		BodyNode:        body,
+		Doc:             doc,
 	}, nil
 }
 
+// leadingString returns the string literal which is the first statement of block body
+func leadingString(body JNode) (string, bool) {
+	if listNode, ok := body.(*JListNode); ok {
+		body = listNode.ElementNodes[0]
+	}
+
+	if stringNode, ok := body.(*JStringNode); ok {
+		return stringNode.Token.Value.(string), true
+	}
+
+	return "", false
+}
+
+// funcArgs parses args with optional type annotations of function definition, from '(' to ')'
+func (p *JParser) funcArgs() ([]*token.JToken, []*token.JToken, error) {
+	if p.CurrentToken.Type != token.LPAREN {
+		return nil, nil, p.createInvalidSyntaxError("'('", "function definition")
+	}
+
+	p.advance()
+	p.skipNewlines()
+
+	var argTokens, argTypeTokens []*token.JToken
+	if p.CurrentToken.Type == token.IDENTIFIER {
+		for {
+			argTokens = append(argTokens, p.CurrentToken)
+
+			p.advance()
+
+			typeToken, err := p.optionalTypeAnnotation()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			argTypeTokens = append(argTypeTokens, typeToken)
+			p.skipNewlines()
+
+			if p.CurrentToken.Type != token.COMMA {
+				break
+			}
+
+			p.advance()
+			p.skipNewlines()
+
+			// the last arg can be followed by comma
+			if p.CurrentToken.Type == token.RPAREN {
+				break
+			}
+
+			if p.CurrentToken.Type != token.IDENTIFIER {
+				return nil, nil, p.createInvalidSyntaxError("identifier or ')'", "function definition")
+			}
+		}
+	}
+
+	if p.CurrentToken.Type != token.RPAREN {
+		return nil, nil, p.createInvalidSyntaxError("')'", "function definition")
+	}
+
+	p.advance()
+
+	return argTokens, argTypeTokens, nil
+}
+
+// IsTypeName reports whether name is a type which can be used in type annotations and impl blocks
+func IsTypeName(name string) bool {
+	return typeNames.Contains(name)
+}
+
+// typeNames are names which can be used in type annotations
+var typeNames = set.NewSet(
+	"any", "number", "string", "bytes", "list", "tuple", "map", "range", "iterator", "function", "error", "enum", "trait",
+	"ast",
+)
+
+func (p *JParser) isReturnTypeAnnotation() bool {
+	if p.CurrentToken.Type != token.ARROW || p.TokenIndex+2 >= len(p.Tokens) {
+		return false
+	}
+
+	typeToken := p.Tokens[p.TokenIndex+1]
+
+	return typeToken.Type == token.IDENTIFIER && typeNames.Contains(typeToken.Value) &&
+		p.Tokens[p.TokenIndex+2].Type == token.NEWLINE
+}
+
+// optionalTypeAnnotation parses ': type' if the current token is colon, nil is returned if there is no annotation
+func (p *JParser) optionalTypeAnnotation() (*token.JToken, error) {
+	if p.CurrentToken.Type != token.COLON {
+		return nil, nil
+	}
+
+	p.advance()
+
+	if p.CurrentToken.Type != token.IDENTIFIER || !typeNames.Contains(p.CurrentToken.Value) {
+		return nil, p.createInvalidSyntaxError(
+			"type name (any, number, string, bytes, list, tuple, map, range, iterator, function, error or enum)",
+			"type annotation",
+		)
+	}
+
+	typeToken := p.CurrentToken
+	p.advance()
+
+	return typeToken, nil
+}
+
 func (p *JParser) atom() (JNode, error) {
 	currentToken := p.CurrentToken
 
@@ -590,6 +983,16 @@ func (p *JParser) atom() (JNode, error) {
 				EndPos:   currentToken.EndPos,
 			},
 		}, nil
+	case token.BYTES:
+		p.advance()
+
+		return &JBytesNode{
+			JBaseNode: &JBaseNode{
+				Token:    currentToken,
+				StartPos: currentToken.StartPos,
+				EndPos:   currentToken.EndPos,
+			},
+		}, nil
 	case token.IDENTIFIER:
 		p.advance()
 
@@ -607,19 +1010,7 @@ func (p *JParser) atom() (JNode, error) {
 			return atomNode, nil
 		}
 	case token.LPAREN:
-		p.advance()
-		expr, err := p.expr()
-		if err != nil {
-			return nil, err
-		}
-
-		if p.CurrentToken.Type != token.RPAREN {
-			return nil, p.createInvalidSyntaxError("')'", "LPAREN")
-		}
-
-		p.advance()
-
-		return expr, nil
+		return p.parenExpr()
 	case token.LSQUARE:
 		return p.listExpr()
 	case token.LBRACE:
@@ -634,6 +1025,10 @@ func (p *JParser) atom() (JNode, error) {
 			return p.whileExpr()
 		case token.FUN:
 			return p.funcDef()
+		case token.QUOTE:
+			return p.quoteExpr()
+		case token.UNQUOTE:
+			return p.unquoteExpr()
 		}
 	}
 
@@ -647,149 +1042,444 @@ func (p *JParser) call() (JNode, error) {
 	}
 
 	if _, ok := atom.(*JVarAccessNode); ok {
-		if val, ok := atom.GetToken().Value.(string); ok && val == "@" {
-			if p.CurrentToken.Type == token.STRING {
-				currentToken := p.CurrentToken
-				p.advance()
-
-				return &JCallExprNode{
-					JBaseNode: &JBaseNode{
-						StartPos: atom.GetStartPos(),
-						EndPos:   p.CurrentToken.EndPos,
-					},
-					CallNode: atom,
-					ArgNodes: []JNode{
-						&JStringNode{
-							JBaseNode: &JBaseNode{
-								Token:    currentToken,
-								StartPos: currentToken.StartPos,
-								EndPos:   currentToken.EndPos,
-							},
-						},
-					},
-				}, nil
-			}
+		if val, ok := atom.GetToken().Value.(string); ok && val == "@" && p.CurrentToken.Type == token.STRING {
+			return p.shellExpr(atom)
 		}
 	}
 
-	if p.CurrentToken.Type == token.LPAREN {
-		p.advance()
+	return p.postfixExpr(atom)
+}
 
-		var argNodes []JNode
-		if p.CurrentToken.Type == token.RPAREN {
-			p.advance()
-		} else {
-			expr, err := p.expr()
-			if err != nil {
-				return nil, p.createInvalidSyntaxError(
-					"Expected ')', 'if', 'for', 'while', 'fun', number, identifier, '+', '-', '(', '[' or 'not'",
-					"call expression",
-				)
-			}
+// shellExpr parses shell literal @"..." following @, {expr} in the string is replaced by shell-quoted value of expr,
+// {!expr} by value of expr without quoting, and {{ and }} by { and }. ${...} and text in single quotes of shell
+// are kept as they are
+func (p *JParser) shellExpr(atom JNode) (JNode, error) {
+	strToken := p.CurrentToken
+	text := strToken.Value.(string)
 
-			argNodes = append(argNodes, expr)
+	shellNode := &JShellNode{
+		JBaseNode: &JBaseNode{
+			StartPos: atom.GetStartPos(),
+			EndPos:   strToken.EndPos,
+		},
+		CallNode: atom,
+	}
 
-			for p.CurrentToken.Type == token.COMMA {
-				p.advance()
+	var fragment strings.Builder
+
+	isDoubleQuoted := false
+
+	for index := 0; index < len(text); index++ {
+		char := text[index]
+
+		switch {
+		case char == '\\' && index+1 < len(text) && strings.IndexByte(`\'"`, text[index+1]) >= 0:
+			// escaped quote doesn't start or end quoted text of shell
+			fragment.WriteString(text[index : index+2])
+			index++
+		case char == '"':
+			isDoubleQuoted = !isDoubleQuoted
+			fragment.WriteByte(char)
+		case char == '\'' && !isDoubleQuoted:
+			// text in single quotes is passed through, eg. braces of awk '{print $1}'
+			endIndex := strings.IndexByte(text[index+1:], '\'')
+			if endIndex < 0 {
+				endIndex = len(text) - 1
+			} else {
+				endIndex += index + 1
+			}
 
-				expr, err := p.expr()
-				if err != nil {
-					return nil, err
-				}
-				argNodes = append(argNodes, expr)
+			fragment.WriteString(text[index : endIndex+1])
+			index = endIndex
+		case char == '$' && index+1 < len(text) && text[index+1] == '{':
+			// ${name} is parameter expansion of shell rather than interpolation
+			endIndex := matchingBrace(text, index+1)
+			if endIndex < 0 {
+				return nil, p.createInvalidSyntaxError("'}' to close '${' of shell literal", "shell literal")
 			}
 
-			if p.CurrentToken.Type != token.RPAREN {
-				return nil, p.createInvalidSyntaxError("',' or ')'", "call expression")
+			fragment.WriteString(text[index : endIndex+1])
+			index = endIndex
+		case (char == '{' || char == '}') && index+1 < len(text) && text[index+1] == char:
+			fragment.WriteByte(char)
+			index++
+		case char == '{':
+			endIndex := matchingBrace(text, index)
+			if endIndex < 0 {
+				return nil, p.createInvalidSyntaxError("'}' to close '{' of shell literal", "shell literal")
 			}
 
-			p.advance()
-		}
+			exprText := text[index+1 : endIndex]
+			isRaw := strings.HasPrefix(exprText, "!")
 
-		var endPos *common.JPosition
-		if len(argNodes) > 0 {
-			endPos = argNodes[len(argNodes)-1].GetEndPos()
-		} else {
-			endPos = atom.GetEndPos()
-		}
+			valueNode, err := parseShellValue(strToken, strings.TrimPrefix(exprText, "!"))
+			if err != nil {
+				return nil, err
+			}
 
-		return &JCallExprNode{
-			JBaseNode: &JBaseNode{
-				StartPos: atom.GetStartPos(),
-				EndPos:   endPos,
-			},
-			CallNode: atom,
-			ArgNodes: argNodes,
-		}, nil
+			shellNode.Fragments = append(shellNode.Fragments, fragment.String())
+			shellNode.ValueNodes = append(shellNode.ValueNodes, valueNode)
+			shellNode.RawValues = append(shellNode.RawValues, isRaw)
+			shellNode.DoubleQuoted = append(shellNode.DoubleQuoted, isDoubleQuoted)
+			fragment.Reset()
+
+			index = endIndex
+		case char == '}':
+			return nil, p.createInvalidSyntaxError("'}}' for single '}' in shell literal", "shell literal")
+		default:
+			fragment.WriteByte(char)
+		}
 	}
 
-	return atom, nil
-}
+	shellNode.Fragments = append(shellNode.Fragments, fragment.String())
 
-func (p *JParser) power() (JNode, error) {
-	return p.binOp(p.call, set.NewSet(token.POW), p.factor)
-}
+	p.advance()
 
-func (p *JParser) factor() (JNode, error) {
-	currentToken := p.CurrentToken
+	return shellNode, nil
+}
 
-	switch currentToken.Type {
-	case token.PLUS, token.MINUS:
-		p.advance()
-		factor, err := p.factor()
-		if err != nil {
-			return nil, err
+// matchingBrace returns index of '}' closing '{' at startIndex of text, braces in strings are skipped.
+// -1 is returned if '{' isn't closed
+func matchingBrace(text string, startIndex int) int {
+	depth := 0
+
+	for index := startIndex; index < len(text); index++ {
+		switch text[index] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return index
+			}
+		case '"', '\'':
+			quote := text[index]
+			for index++; index < len(text) && text[index] != quote; index++ {
+				if text[index] == '\\' {
+					index++
+				}
+			}
 		}
+	}
 
-		return &JUnaryOpNode{
-			JBaseNode: &JBaseNode{
-				Token:    currentToken,
-				StartPos: currentToken.StartPos,
-				EndPos:   factor.GetEndPos(),
+	return -1
+}
+
+// parseShellValue parses expression in braces of shell literal, nodes of the expression are positioned at
+// the shell literal because the string has been unescaped by lexer
+func parseShellValue(strToken *token.JToken, exprText string) (JNode, error) {
+	createError := func() error {
+		return errors.Wrap(&common.JInvalidSyntaxError{
+			JError: &common.JError{
+				StartPos: strToken.StartPos,
+				EndPos:   strToken.EndPos,
 			},
-			Node: factor,
-		}, nil
+			Details: fmt.Sprintf("Expected expression in '{%s}' of shell literal", exprText),
+		}, "failed to parse shell literal")
+	}
 
-	default:
-		return p.power()
+	tokens, err := lexer.NewJLexer(strToken.StartPos.Filename, exprText).MakeTokens()
+	if err != nil {
+		return nil, createError()
 	}
-}
 
-func (p *JParser) term() (JNode, error) {
-	return p.binOp(p.factor, set.NewSet(token.MUL, token.DIV), nil)
-}
+	for _, tok := range tokens {
+		tok.StartPos, tok.EndPos = strToken.StartPos, strToken.EndPos
+	}
 
-func (p *JParser) arithmeticExpr() (JNode, error) {
-	return p.binOp(p.term, set.NewSet(token.PLUS, token.MINUS), nil)
+	node, err := NewJParser(tokens, -1).ParseExpr()
+	if err != nil {
+		return nil, createError()
+	}
+
+	return node, nil
 }
 
-func (p *JParser) compareExpr() (JNode, error) {
-	currentToken := p.CurrentToken
+// postfixExpr parses calls, member accesses and index accesses following node, eg. a.b?.c?["d"](1)[0]
+func (p *JParser) postfixExpr(node JNode) (JNode, error) {
+	var err error
 
-	if currentToken.Match(token.KEYWORD, token.NOT) {
-		p.advance()
+	for {
+		switch p.CurrentToken.Type {
+		case token.LPAREN:
+			node, err = p.callExpr(node)
+		case token.DOT, token.QDOT:
+			node, err = p.memberAccessExpr(node)
+		case token.QLSQUARE:
+			node, err = p.nullSafeIndexExpr(node)
+		case token.LSQUARE:
+			node, err = p.indexExpr(node)
+		default:
+			return node, nil
+		}
 
-		compExpr, err := p.compareExpr()
 		if err != nil {
 			return nil, err
 		}
-
-		return &JUnaryOpNode{
-			JBaseNode: &JBaseNode{
-				Token:    currentToken,
-				StartPos: currentToken.StartPos,
-				EndPos:   compExpr.GetEndPos(),
-			},
-			Node: compExpr,
-		}, nil
 	}
-
-	return p.binOp(p.arithmeticExpr, set.NewSet(token.EE, token.NE, token.LT, token.LTE, token.GT, token.GTE), nil)
 }
 
-func (p *JParser) expr() (JNode, error) {
-	// check if it is an assignment expression
-	if p.CurrentToken.Type == token.IDENTIFIER {
+func (p *JParser) callExpr(atom JNode) (JNode, error) {
+	p.advance()
+	p.skipNewlines()
+
+	// newlines are ignored inside parentheses, and the last arg can be followed by comma
+	var argNodes []JNode
+	for p.CurrentToken.Type != token.RPAREN {
+		expr, err := p.expr()
+		if err != nil {
+			if len(argNodes) > 0 {
+				return nil, err
+			}
+
+			return nil, p.createInvalidSyntaxError(
+				"Expected ')', 'if', 'for', 'while', 'fun', number, identifier, '+', '-', '(', '[' or 'not'",
+				"call expression",
+			)
+		}
+
+		argNodes = append(argNodes, expr)
+		p.skipNewlines()
+
+		if p.CurrentToken.Type != token.COMMA {
+			break
+		}
+
+		p.advance()
+		p.skipNewlines()
+	}
+
+	if p.CurrentToken.Type != token.RPAREN {
+		return nil, p.createInvalidSyntaxError("',' or ')'", "call expression")
+	}
+
+	p.advance()
+
+	var endPos *common.JPosition
+	if len(argNodes) > 0 {
+		endPos = argNodes[len(argNodes)-1].GetEndPos()
+	} else {
+		endPos = atom.GetEndPos()
+	}
+
+	return &JCallExprNode{
+		JBaseNode: &JBaseNode{
+			StartPos: atom.GetStartPos(),
+			EndPos:   endPos,
+		},
+		CallNode: atom,
+		ArgNodes: argNodes,
+	}, nil
+}
+
+// memberAccessExpr parses a.b and the null-safe a?.b
+func (p *JParser) memberAccessExpr(node JNode) (JNode, error) {
+	isNullSafe := p.CurrentToken.Type == token.QDOT
+
+	p.advance()
+
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil, p.createInvalidSyntaxError("identifier", "member access expression")
+	}
+
+	nameToken := p.CurrentToken
+	p.advance()
+
+	return &JMemberAccessNode{
+		JBaseNode: &JBaseNode{
+			Token:    nameToken,
+			StartPos: node.GetStartPos(),
+			EndPos:   nameToken.EndPos,
+		},
+		Node:       node,
+		IsNullSafe: isNullSafe,
+	}, nil
+}
+
+// nullSafeIndexExpr parses a?[b]
+func (p *JParser) nullSafeIndexExpr(node JNode) (JNode, error) {
+	p.advance()
+	p.skipNewlines()
+
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipNewlines()
+
+	if p.CurrentToken.Type != token.RSQUARE {
+		return nil, p.createInvalidSyntaxError("']'", "index expression")
+	}
+
+	p.advance()
+
+	return &JIndexExprNode{
+		JBaseNode: &JBaseNode{
+			StartPos: node.GetStartPos(),
+			EndPos:   p.CurrentToken.EndPos.Copy().Back(nil),
+		},
+		IndexNode:  node,
+		IndexExpr:  expr,
+		IsNullSafe: true,
+	}, nil
+}
+
+func (p *JParser) power() (JNode, error) {
+	return p.binOp(p.call, set.NewSet(token.POW), p.factor)
+}
+
+func (p *JParser) factor() (JNode, error) {
+	currentToken := p.CurrentToken
+
+	switch currentToken.Type {
+	case token.PLUS, token.MINUS:
+		p.advance()
+		factor, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+
+		return &JUnaryOpNode{
+			JBaseNode: &JBaseNode{
+				Token:    currentToken,
+				StartPos: currentToken.StartPos,
+				EndPos:   factor.GetEndPos(),
+			},
+			Node: factor,
+		}, nil
+
+	default:
+		return p.power()
+	}
+}
+
+func (p *JParser) term() (JNode, error) {
+	return p.binOp(p.factor, set.NewSet(token.MUL, token.DIV), nil)
+}
+
+func (p *JParser) arithmeticExpr() (JNode, error) {
+	return p.binOp(p.term, set.NewSet(token.PLUS, token.MINUS), nil)
+}
+
+func (p *JParser) compareExpr() (JNode, error) {
+	currentToken := p.CurrentToken
+
+	if currentToken.Match(token.KEYWORD, token.NOT) {
+		p.advance()
+
+		compExpr, err := p.compareExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		return &JUnaryOpNode{
+			JBaseNode: &JBaseNode{
+				Token:    currentToken,
+				StartPos: currentToken.StartPos,
+				EndPos:   compExpr.GetEndPos(),
+			},
+			Node: compExpr,
+		}, nil
+	}
+
+	leftNode, err := p.pipeExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	ops := set.NewSet(token.EE, token.NE, token.LT, token.LTE, token.GT, token.GTE, token.IN, token.IS)
+
+	for (p.CurrentToken.Type == token.KEYWORD && ops.Contains(p.CurrentToken.Value)) ||
+		ops.Contains(p.CurrentToken.Type) || p.CurrentToken.Match(token.KEYWORD, token.NOT) {
+
+		opToken := p.CurrentToken
+		p.advance()
+
+		// 'a not in b' and 'a is not b' are parsed as 'not (a in b)' and 'not (a is b)'
+		var notToken *token.JToken
+		if opToken.Match(token.KEYWORD, token.NOT) {
+			if !p.CurrentToken.Match(token.KEYWORD, token.IN) {
+				return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.IN), "compare expression")
+			}
+
+			notToken, opToken = opToken, p.CurrentToken
+			p.advance()
+		} else if opToken.Match(token.KEYWORD, token.IS) && p.CurrentToken.Match(token.KEYWORD, token.NOT) {
+			notToken = p.CurrentToken
+			p.advance()
+		}
+
+		p.skipNewlines()
+
+		rightNode, err := p.pipeExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		leftNode = &JBinOpNode{
+			JBaseNode: &JBaseNode{
+				Token:    opToken,
+				StartPos: leftNode.GetStartPos(),
+				EndPos:   rightNode.GetEndPos(),
+			},
+			LeftNode:  leftNode,
+			RightNode: rightNode,
+		}
+
+		if notToken != nil {
+			leftNode = &JUnaryOpNode{
+				JBaseNode: &JBaseNode{
+					Token:    notToken,
+					StartPos: leftNode.GetStartPos(),
+					EndPos:   leftNode.GetEndPos(),
+				},
+				Node: leftNode,
+			}
+		}
+	}
+
+	return leftNode, nil
+}
+
+// rangeExpr parses start..end, end is exclusive
+func (p *JParser) rangeExpr() (JNode, error) {
+	leftNode, err := p.arithmeticExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.CurrentToken.Type != token.DOTDOT {
+		return leftNode, nil
+	}
+
+	opToken := p.CurrentToken
+	p.advance()
+	p.skipNewlines()
+
+	rightNode, err := p.arithmeticExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JBinOpNode{
+		JBaseNode: &JBaseNode{
+			Token:    opToken,
+			StartPos: leftNode.GetStartPos(),
+			EndPos:   rightNode.GetEndPos(),
+		},
+		LeftNode:  leftNode,
+		RightNode: rightNode,
+	}, nil
+}
+
+// coalesceExpr parses a ?? b, b is evaluated only if a is null
+func (p *JParser) coalesceExpr() (JNode, error) {
+	return p.binOp(p.compareExpr, set.NewSet(token.QQ), nil)
+}
+
+func (p *JParser) expr() (JNode, error) {
+	// check if it is an assignment expression
+	if p.CurrentToken.Type == token.IDENTIFIER {
 		varToken := p.CurrentToken
 		p.advance()
 
@@ -818,12 +1508,83 @@ func (p *JParser) expr() (JNode, error) {
 		}
 	}
 
-	return p.binOp(p.compareExpr, set.NewSet(token.AND, token.OR), nil)
+	return p.binOp(p.coalesceExpr, set.NewSet(token.AND, token.OR), nil)
+}
+
+// pipeExpr parses x |> f(a) which calls f(x, a), the pipe operator can start a new line.
+// It binds tighter than comparison and looser than range, so xs |> len > 1 is len(xs) > 1
+// and 0..3 |> list is list(0..3)
+func (p *JParser) pipeExpr() (JNode, error) {
+	node, err := p.rangeExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tokenIndex := p.TokenIndex
+		p.skipNewlines()
+
+		if p.CurrentToken.Type != token.PIPE {
+			p.backTo(tokenIndex)
+
+			return node, nil
+		}
+
+		p.advance()
+		p.skipNewlines()
+
+		callNode, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+
+		// x |> f is f(x), x |> f(a) is f(x, a)
+		rewrittenCallNode, ok := callNode.(*JCallExprNode)
+		if ok {
+			argNodes := make([]JNode, 0, len(rewrittenCallNode.ArgNodes)+1)
+			argNodes = append(argNodes, node)
+			argNodes = append(argNodes, rewrittenCallNode.ArgNodes...)
+
+			rewrittenCallNode = &JCallExprNode{
+				JBaseNode: rewrittenCallNode.JBaseNode,
+				CallNode:  rewrittenCallNode.CallNode,
+				ArgNodes:  argNodes,
+			}
+		} else {
+			rewrittenCallNode = &JCallExprNode{
+				JBaseNode: &JBaseNode{
+					StartPos: callNode.GetStartPos(),
+					EndPos:   callNode.GetEndPos(),
+				},
+				CallNode: callNode,
+				ArgNodes: []JNode{node},
+			}
+		}
+
+		node = &JPipeExprNode{
+			JBaseNode: &JBaseNode{
+				StartPos: node.GetStartPos(),
+				EndPos:   callNode.GetEndPos(),
+			},
+			ValueNode: node,
+			CallNode:  rewrittenCallNode,
+		}
+	}
+}
+
+func (p *JParser) skipNewlines() {
+	for p.CurrentToken.Type == token.NEWLINE {
+		p.advance()
+	}
 }
 
 func (p *JParser) statement() (JNode, error) {
 	currentToken := p.CurrentToken
 
+	if p.isDecorator() {
+		return p.decoratedFuncDef()
+	}
+
 	if currentToken.IsKeyWord() {
 		switch p.CurrentToken.Value {
 		case token.RETURN:
@@ -850,8 +1611,37 @@ func (p *JParser) statement() (JNode, error) {
 				},
 				ReturnNode: expr,
 			}, nil
+		case token.ENUM:
+			return p.enumDef()
+		case token.TRAIT:
+			return p.traitDef()
+		case token.IMPL:
+			return p.implDef()
+		case token.MACRO:
+			return p.macroDef()
+		case token.LET, token.CONST:
+			return p.varDeclareExpr()
+		case token.GLOBAL, token.NONLOCAL:
+			return p.scopeDeclareExpr()
+		case token.DEFER:
+			p.advance()
+
+			expr, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+
+			return &JDeferNode{
+				JBaseNode: &JBaseNode{
+					Token:    currentToken,
+					StartPos: currentToken.StartPos,
+					EndPos:   expr.GetEndPos(),
+				},
+				DeferNode: expr,
+			}, nil
 		case token.BREAK:
 			p.advance()
+			labelToken := p.loopControlLabel()
 
 			return &JBreakNode{
 				JBaseNode: &JBaseNode{
@@ -859,9 +1649,11 @@ func (p *JParser) statement() (JNode, error) {
 					StartPos: currentToken.StartPos,
 					EndPos:   currentToken.EndPos,
 				},
+				LabelToken: labelToken,
 			}, nil
 		case token.CONTINUE:
 			p.advance()
+			labelToken := p.loopControlLabel()
 
 			return &JContinueNode{
 				JBaseNode: &JBaseNode{
@@ -869,28 +1661,699 @@ func (p *JParser) statement() (JNode, error) {
 					StartPos: currentToken.StartPos,
 					EndPos:   p.CurrentToken.EndPos,
 				},
+				LabelToken: labelToken,
 			}, nil
 		}
 	}
 
+	if currentToken.Type == token.IDENTIFIER && p.isLoopLabel() {
+		return p.labeledLoopExpr()
+	}
+
+	if currentToken.Type == token.IDENTIFIER && p.isTypedAssign() {
+		return p.typedAssignExpr()
+	}
+
+	if currentToken.Type == token.IDENTIFIER {
+		if unpackAssignNode, err := p.unpackAssignExpr(); unpackAssignNode != nil || err != nil {
+			return unpackAssignNode, err
+		}
+	}
+
 	return p.expr()
 }
 
-func (p *JParser) statements(isBlock bool) (JNode, error) {
-	startPos := p.CurrentToken.StartPos
+// enumDef parses enum Name a, b = expr, c end, members are separated by comma or new line
+func (p *JParser) enumDef() (JNode, error) {
+	enumToken := p.CurrentToken
+	p.advance()
 
-	for p.CurrentToken.Type == token.NEWLINE {
-		p.advance()
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil, p.createInvalidSyntaxError("identifier", "enum declaration")
 	}
 
-	statementNode, err := p.statement()
-	if err != nil {
-		return nil, err
-	}
+	nameToken := p.CurrentToken
+	p.advance()
 
-	statementNodes := []JNode{statementNode}
+	var (
+		memberTokens []*token.JToken
+		valueNodes   []JNode
+	)
+
+	memberNames := make(map[any]struct{})
+
+	for {
+		p.skipNewlines()
+
+		if p.CurrentToken.Match(token.KEYWORD, token.END) && len(memberTokens) > 0 {
+			break
+		}
+
+		if p.CurrentToken.Type != token.IDENTIFIER {
+			return nil, p.createInvalidSyntaxError("identifier", "enum declaration")
+		}
+
+		if _, ok := memberNames[p.CurrentToken.Value]; ok {
+			return nil, errors.Wrap(&common.JInvalidSyntaxError{
+				JError: &common.JError{
+					StartPos: p.CurrentToken.StartPos,
+					EndPos:   p.CurrentToken.EndPos,
+				},
+				Details: fmt.Sprintf("Duplicate member '%v' of enum", p.CurrentToken.Value),
+			}, "failed to parse enum declaration")
+		}
+
+		memberNames[p.CurrentToken.Value] = struct{}{}
+		memberTokens = append(memberTokens, p.CurrentToken)
+		p.advance()
+
+		var valueNode JNode
+		if p.CurrentToken.Type == token.EQ {
+			p.advance()
+
+			expr, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+
+			valueNode = expr
+		}
+
+		valueNodes = append(valueNodes, valueNode)
+
+		if p.CurrentToken.Type == token.COMMA {
+			p.advance()
+		} else if p.CurrentToken.Type != token.NEWLINE && !p.CurrentToken.Match(token.KEYWORD, token.END) {
+			return nil, p.createInvalidSyntaxError(fmt.Sprintf("',', NEWLINE or '%s'", token.END), "enum declaration")
+		}
+	}
+
+	endToken := p.CurrentToken
+	p.advance()
+
+	return &JEnumDefNode{
+		JBaseNode: &JBaseNode{
+			Token:    nameToken,
+			StartPos: enumToken.StartPos,
+			EndPos:   endToken.EndPos,
+		},
+		MemberTokens: memberTokens,
+		ValueNodes:   valueNodes,
+	}, nil
+}
+
+// traitDef parses trait declaration, methods of trait have no body and take self as the first arg
+func (p *JParser) traitDef() (JNode, error) {
+	traitToken := p.CurrentToken
+	p.advance()
+
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil, p.createInvalidSyntaxError("identifier", "trait declaration")
+	}
+
+	nameToken := p.CurrentToken
+	p.advance()
+
+	var (
+		methodTokens    []*token.JToken
+		methodArgTokens [][]*token.JToken
+	)
+
+	methodNames := make(map[any]struct{})
+
+	for {
+		p.skipNewlines()
+
+		if p.CurrentToken.Match(token.KEYWORD, token.END) && len(methodTokens) > 0 {
+			break
+		}
+
+		if !p.CurrentToken.Match(token.KEYWORD, token.FUN) {
+			return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.FUN), "trait declaration")
+		}
+
+		p.advance()
+
+		if p.CurrentToken.Type != token.IDENTIFIER {
+			return nil, p.createInvalidSyntaxError("identifier", "trait declaration")
+		}
+
+		methodToken := p.CurrentToken
+		p.advance()
+
+		if _, ok := methodNames[methodToken.Value]; ok {
+			return nil, p.createMethodError(methodToken, fmt.Sprintf("Duplicate method '%v' of trait", methodToken.Value))
+		}
+
+		argTokens, _, err := p.funcArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(argTokens) == 0 {
+			return nil, p.createMethodError(methodToken, fmt.Sprintf("Method '%v' of trait must take self", methodToken.Value))
+		}
+
+		methodNames[methodToken.Value] = struct{}{}
+		methodTokens = append(methodTokens, methodToken)
+		methodArgTokens = append(methodArgTokens, argTokens)
+
+		if p.CurrentToken.Type != token.NEWLINE && !p.CurrentToken.Match(token.KEYWORD, token.END) {
+			return nil, p.createInvalidSyntaxError(fmt.Sprintf("NEWLINE or '%s'", token.END), "trait declaration")
+		}
+	}
+
+	endToken := p.CurrentToken
+	p.advance()
+
+	return &JTraitDefNode{
+		JBaseNode: &JBaseNode{
+			Token:    nameToken,
+			StartPos: traitToken.StartPos,
+			EndPos:   endToken.EndPos,
+		},
+		MethodTokens:    methodTokens,
+		MethodArgTokens: methodArgTokens,
+	}, nil
+}
+
+// implDef parses impl block, which defines methods of trait for enum or type, eg. impl Printable for Point
+func (p *JParser) implDef() (JNode, error) {
+	implToken := p.CurrentToken
+	p.advance()
+
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil, p.createInvalidSyntaxError("identifier", "impl block")
+	}
+
+	traitToken := p.CurrentToken
+	p.advance()
+
+	if !p.CurrentToken.Match(token.KEYWORD, token.FOR) {
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.FOR), "impl block")
+	}
+
+	p.advance()
+
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil, p.createInvalidSyntaxError("identifier", "impl block")
+	}
+
+	targetToken := p.CurrentToken
+	p.advance()
+
+	var methodNodes []*JFuncDefNode
+
+	methodNames := make(map[any]struct{})
 
-	moreStatements := false
+	for {
+		p.skipNewlines()
+
+		if p.CurrentToken.Match(token.KEYWORD, token.END) {
+			break
+		}
+
+		if !p.CurrentToken.Match(token.KEYWORD, token.FUN) {
+			return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s' or '%s'", token.FUN, token.END), "impl block")
+		}
+
+		funToken := p.CurrentToken
+
+		node, err := p.funcDef()
+		if err != nil {
+			return nil, err
+		}
+
+		methodNode := node.(*JFuncDefNode)
+		if methodNode.Token == nil {
+			return nil, p.createMethodError(funToken, "Method of impl block must have name")
+		}
+
+		if _, ok := methodNames[methodNode.Token.Value]; ok {
+			return nil, p.createMethodError(methodNode.Token, fmt.Sprintf("Duplicate method '%v' of impl block", methodNode.Token.Value))
+		}
+
+		methodNames[methodNode.Token.Value] = struct{}{}
+		methodNodes = append(methodNodes, methodNode)
+
+		if p.CurrentToken.Type != token.NEWLINE && !p.CurrentToken.Match(token.KEYWORD, token.END) {
+			return nil, p.createInvalidSyntaxError(fmt.Sprintf("NEWLINE or '%s'", token.END), "impl block")
+		}
+	}
+
+	endToken := p.CurrentToken
+	p.advance()
+
+	return &JImplDefNode{
+		JBaseNode: &JBaseNode{
+			Token:    traitToken,
+			StartPos: implToken.StartPos,
+			EndPos:   endToken.EndPos,
+		},
+		TargetToken: targetToken,
+		MethodNodes: methodNodes,
+	}, nil
+}
+
+func (p *JParser) createMethodError(methodToken *token.JToken, details string) error {
+	return errors.Wrap(&common.JInvalidSyntaxError{
+		JError: &common.JError{
+			StartPos: methodToken.StartPos,
+			EndPos:   methodToken.EndPos,
+		},
+		Details: details,
+	}, "failed to parse method")
+}
+
+// macroDef parses macro name(args) body like named function definition
+func (p *JParser) macroDef() (JNode, error) {
+	macroToken := p.CurrentToken
+	p.advance()
+
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil, p.createInvalidSyntaxError("identifier", "macro definition")
+	}
+
+	nameToken := p.CurrentToken
+	p.advance()
+
+	funcDefNode, err := p.funcDefBody(macroToken, nameToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JMacroDefNode{
+		JBaseNode: &JBaseNode{
+			Token:    nameToken,
+			StartPos: funcDefNode.StartPos,
+			EndPos:   funcDefNode.EndPos,
+		},
+		FuncDefNode: funcDefNode,
+	}, nil
+}
+
+// quoteExpr parses quote(expr) or quote NEWLINE statements end
+func (p *JParser) quoteExpr() (JNode, error) {
+	quoteToken := p.CurrentToken
+	p.advance()
+
+	p.quoteDepth++
+	defer func() { p.quoteDepth-- }()
+
+	if p.CurrentToken.Type != token.NEWLINE {
+		node, endPos, err := p.parenthesizedExpr("quote expression")
+		if err != nil {
+			return nil, err
+		}
+
+		return &JQuoteNode{
+			JBaseNode: &JBaseNode{
+				Token:    quoteToken,
+				StartPos: quoteToken.StartPos,
+				EndPos:   endPos,
+			},
+			Node: node,
+		}, nil
+	}
+
+	p.advance()
+
+	body, err := p.statements(true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.CurrentToken.Match(token.KEYWORD, token.END) {
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s'", token.END), "quote expression")
+	}
+
+	endPos := p.CurrentToken.EndPos
+	p.advance()
+
+	return &JQuoteNode{
+		JBaseNode: &JBaseNode{
+			Token:    quoteToken,
+			StartPos: quoteToken.StartPos,
+			EndPos:   endPos,
+		},
+		Node: body,
+	}, nil
+}
+
+// unquoteExpr parses unquote(expr), which is only allowed inside quote
+func (p *JParser) unquoteExpr() (JNode, error) {
+	unquoteToken := p.CurrentToken
+	if p.quoteDepth == 0 {
+		return nil, errors.Wrap(&common.JInvalidSyntaxError{
+			JError: &common.JError{
+				StartPos: unquoteToken.StartPos,
+				EndPos:   unquoteToken.EndPos,
+			},
+			Details: "unquote is only allowed inside quote",
+		}, "failed to parse unquote expression")
+	}
+
+	p.advance()
+
+	// the unquoted expression is evaluated by the macro, so it may contain quote again
+	quoteDepth := p.quoteDepth
+	p.quoteDepth = 0
+	node, endPos, err := p.parenthesizedExpr("unquote expression")
+	p.quoteDepth = quoteDepth
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &JUnquoteNode{
+		JBaseNode: &JBaseNode{
+			Token:    unquoteToken,
+			StartPos: unquoteToken.StartPos,
+			EndPos:   endPos,
+		},
+		Node: node,
+	}, nil
+}
+
+// parenthesizedExpr parses '(' expr ')' and returns the expression and end position of ')'
+func (p *JParser) parenthesizedExpr(parseType string) (JNode, *common.JPosition, error) {
+	if p.CurrentToken.Type != token.LPAREN {
+		return nil, nil, p.createInvalidSyntaxError("'('", parseType)
+	}
+
+	p.advance()
+	p.skipNewlines()
+
+	node, err := p.expr()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.skipNewlines()
+
+	if p.CurrentToken.Type != token.RPAREN {
+		return nil, nil, p.createInvalidSyntaxError("')'", parseType)
+	}
+
+	endPos := p.CurrentToken.EndPos
+	p.advance()
+
+	return node, endPos, nil
+}
+
+// isDecorator checks if the current identifier is @decorator, the lexer reads '@' as part of identifier
+func (p *JParser) isDecorator() bool {
+	name, ok := p.CurrentToken.Value.(string)
+
+	return p.CurrentToken.Type == token.IDENTIFIER && ok && len(name) > 1 && strings.HasPrefix(name, "@")
+}
+
+// decoratedFuncDef parses decorator lines followed by named function definition, eg. @retry(3) NEWLINE fun f() ...
+func (p *JParser) decoratedFuncDef() (JNode, error) {
+	var decoratorNodes []JNode
+
+	// doc comments are before the first decorator
+	doc := p.CurrentToken.Doc
+
+	for p.isDecorator() {
+		atToken := p.CurrentToken
+		nameToken := token.NewJToken(token.IDENTIFIER, strings.TrimPrefix(atToken.Value.(string), "@"),
+			atToken.StartPos, atToken.EndPos)
+		p.advance()
+
+		decoratorNode, err := p.postfixExpr(&JVarAccessNode{
+			JBaseNode: &JBaseNode{
+				Token:    nameToken,
+				StartPos: nameToken.StartPos,
+				EndPos:   nameToken.EndPos,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if p.CurrentToken.Type != token.NEWLINE {
+			return nil, p.createInvalidSyntaxError("NEWLINE", "decorator")
+		}
+
+		p.skipNewlines()
+
+		decoratorNodes = append(decoratorNodes, decoratorNode)
+	}
+
+	if !p.CurrentToken.Match(token.KEYWORD, token.FUN) {
+		return nil, p.createInvalidSyntaxError(fmt.Sprintf("'%s' or decorator", token.FUN), "decorator")
+	}
+
+	funToken := p.CurrentToken
+
+	node, err := p.funcDef()
+	if err != nil {
+		return nil, err
+	}
+
+	funcDefNode := node.(*JFuncDefNode)
+	if funcDefNode.Token == nil {
+		return nil, errors.Wrap(&common.JInvalidSyntaxError{
+			JError: &common.JError{
+				StartPos: funToken.StartPos,
+				EndPos:   funToken.EndPos,
+			},
+			Details: "Decorated function must have name",
+		}, "failed to parse decorator")
+	}
+
+	funcDefNode.DecoratorNodes = decoratorNodes
+	if funcDefNode.Doc == "" {
+		funcDefNode.Doc = doc
+	}
+	funcDefNode.StartPos = decoratorNodes[0].GetStartPos()
+
+	return funcDefNode, nil
+}
+
+// isTypedAssign checks if the current identifier starts assignment with type annotation, eg. x: list = []
+func (p *JParser) isTypedAssign() bool {
+	return p.TokenIndex+3 < len(p.Tokens) &&
+		p.Tokens[p.TokenIndex+1].Type == token.COLON &&
+		p.Tokens[p.TokenIndex+2].Type == token.IDENTIFIER &&
+		p.Tokens[p.TokenIndex+3].Type == token.EQ
+}
+
+func (p *JParser) typedAssignExpr() (JNode, error) {
+	varToken := p.CurrentToken
+	p.advance()
+
+	typeToken, err := p.optionalTypeAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
+	p.advance()
+
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JVarAssignNode{
+		JBaseNode: &JBaseNode{
+			Token:    varToken,
+			StartPos: varToken.StartPos,
+			EndPos:   expr.GetEndPos(),
+		},
+		Node:      expr,
+		TypeToken: typeToken,
+	}, nil
+}
+
+// varDeclareExpr parses let x = expr or const x = expr
+func (p *JParser) varDeclareExpr() (JNode, error) {
+	keywordToken := p.CurrentToken
+	p.advance()
+
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil, p.createInvalidSyntaxError("identifier", "variable declaration")
+	}
+
+	varToken := p.CurrentToken
+	p.advance()
+
+	typeToken, err := p.optionalTypeAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.CurrentToken.Type != token.EQ {
+		return nil, p.createInvalidSyntaxError("'='", "variable declaration")
+	}
+
+	p.advance()
+
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JVarDeclareNode{
+		JBaseNode: &JBaseNode{
+			Token:    keywordToken,
+			StartPos: keywordToken.StartPos,
+			EndPos:   expr.GetEndPos(),
+		},
+		VarToken:  varToken,
+		Node:      expr,
+		TypeToken: typeToken,
+	}, nil
+}
+
+// scopeDeclareExpr parses global a, b or nonlocal a, b
+func (p *JParser) scopeDeclareExpr() (JNode, error) {
+	keywordToken := p.CurrentToken
+	p.advance()
+
+	var varTokens []*token.JToken
+
+	for {
+		if p.CurrentToken.Type != token.IDENTIFIER {
+			return nil, p.createInvalidSyntaxError("identifier", keywordToken.Value.(string)+" declaration")
+		}
+
+		varTokens = append(varTokens, p.CurrentToken)
+		p.advance()
+
+		if p.CurrentToken.Type != token.COMMA {
+			break
+		}
+
+		p.advance()
+	}
+
+	return &JScopeDeclareNode{
+		JBaseNode: &JBaseNode{
+			Token:    keywordToken,
+			StartPos: keywordToken.StartPos,
+			EndPos:   varTokens[len(varTokens)-1].EndPos,
+		},
+		VarTokens: varTokens,
+	}, nil
+}
+
+// loopControlLabel parses the optional label after break or continue, eg. break outer
+func (p *JParser) loopControlLabel() *token.JToken {
+	if p.CurrentToken.Type != token.IDENTIFIER {
+		return nil
+	}
+
+	labelToken := p.CurrentToken
+	p.advance()
+
+	return labelToken
+}
+
+// isLoopLabel checks if the current identifier is a label of loop, eg. outer: for ...
+func (p *JParser) isLoopLabel() bool {
+	if p.TokenIndex+2 >= len(p.Tokens) || p.Tokens[p.TokenIndex+1].Type != token.COLON {
+		return false
+	}
+
+	loopToken := p.Tokens[p.TokenIndex+2]
+
+	return loopToken.Match(token.KEYWORD, token.FOR) || loopToken.Match(token.KEYWORD, token.WHILE)
+}
+
+func (p *JParser) labeledLoopExpr() (JNode, error) {
+	labelToken := p.CurrentToken
+	p.advance()
+	p.advance()
+
+	var (
+		loopNode JNode
+		err      error
+	)
+
+	if p.CurrentToken.Match(token.KEYWORD, token.FOR) {
+		loopNode, err = p.forExpr()
+	} else {
+		loopNode, err = p.whileExpr()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := loopNode.(type) {
+	case *JForExprNode:
+		node.LabelToken = labelToken
+	case *JForInExprNode:
+		node.LabelToken = labelToken
+	case *JWhileExprNode:
+		node.LabelToken = labelToken
+	}
+
+	return loopNode, nil
+}
+
+// unpackAssignExpr parses an assignment with several variables, eg. a, b = (1, 2).
+// it returns nil node and nil error if the current statement is not an unpack assignment
+func (p *JParser) unpackAssignExpr() (JNode, error) {
+	tokenIndex := p.TokenIndex
+
+	varTokens := []*token.JToken{p.CurrentToken}
+	p.advance()
+
+	for p.CurrentToken.Type == token.COMMA {
+		p.advance()
+
+		if p.CurrentToken.Type != token.IDENTIFIER {
+			p.backTo(tokenIndex)
+
+			return nil, nil
+		}
+
+		varTokens = append(varTokens, p.CurrentToken)
+		p.advance()
+	}
+
+	if len(varTokens) < 2 || p.CurrentToken.Type != token.EQ {
+		p.backTo(tokenIndex)
+
+		return nil, nil
+	}
+
+	p.advance()
+
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JVarUnpackAssignNode{
+		JBaseNode: &JBaseNode{
+			Token:    varTokens[0],
+			StartPos: varTokens[0].StartPos,
+			EndPos:   expr.GetEndPos(),
+		},
+		VarTokens: varTokens,
+		Node:      expr,
+	}, nil
+}
+
+func (p *JParser) statements(isBlock bool) (JNode, error) {
+	startPos := p.CurrentToken.StartPos
+
+	for p.CurrentToken.Type == token.NEWLINE {
+		p.advance()
+	}
+
+	statementNode, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	statementNodes := []JNode{statementNode}
+
+	moreStatements := false
 	for {
 		for p.CurrentToken.Type == token.NEWLINE {
 			p.advance()
@@ -939,8 +2402,11 @@ func (p *JParser) binOp(getNodeFuncA getNodeFunc, ops *set.Set, getNodeFuncB get
 	for (p.CurrentToken.Type == token.KEYWORD && ops.Contains(p.CurrentToken.Value)) ||
 		ops.Contains(p.CurrentToken.Type) {
 
+		// newlines after binary operator are ignored, so long expressions can be split into lines
 		opToken := p.CurrentToken
 		p.advance()
+		p.skipNewlines()
+
 		rightNode, err := getNodeFuncB()
 		if err != nil {
 			return nil, err
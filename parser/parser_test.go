@@ -45,6 +45,327 @@ func TestJParser_Parse(t *testing.T) {
 
 			},
 		},
+		{
+			name: "tuple",
+			text: "((1), (1,), (), (1, 2,))",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(INT:1, (INT:1,), (), (INT:1, INT:2))"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "unpack assign and slice",
+			text: "a, b = c[1:]",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(IDENTIFIER:a, IDENTIFIER:b = IDENTIFIER:c[INT:1:])"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "for in range",
+			text: "for i in 0..n + 1 then i",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "for (IDENTIFIER:i in (INT:0 DOTDOT (IDENTIFIER:n PLUS INT:1))) {IDENTIFIER:i}"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "labeled loop",
+			text: "outer: while a then for i in b then if i then break outer else continue outer else 1",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "IDENTIFIER:outer: while (IDENTIFIER:a) {for (IDENTIFIER:i in IDENTIFIER:b) {if (IDENTIFIER:i) {KEYWORD:break IDENTIFIER:outer} else KEYWORD:continue IDENTIFIER:outer} else {INT:1}}"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "enum",
+			text: "enum Status ok = 200, created\n not_found = 404 end",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(<ENUM> IDENTIFIER:Status {IDENTIFIER:ok = INT:200, IDENTIFIER:created, IDENTIFIER:not_found = INT:404})"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "duplicate enum member",
+			text: "enum Color red, red end",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+			},
+		},
+		{
+			name: "type annotations",
+			text: "fun add(a: number, b) -> number\n return a + b\n end\n x: list = []\n const y: string = 'a'",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "[(<FUNCTION> IDENTIFIER:add <args>(IDENTIFIER:a: IDENTIFIER:number IDENTIFIER:b) -> IDENTIFIER:number <body>KEYWORD:return (IDENTIFIER:a PLUS IDENTIFIER:b)), (IDENTIFIER:x: IDENTIFIER:list = []), (KEYWORD:const IDENTIFIER:y: IDENTIFIER:string = STRING:a)]"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "unknown type annotation",
+			text: "x: foo = 1",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+			},
+		},
+		{
+			name: "trait and impl",
+			text: "trait Printable fun to_str(self) end\nimpl Printable for Point\n fun to_str(self) -> 'p'\n end",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "[(<TRAIT> IDENTIFIER:Printable {IDENTIFIER:to_str(IDENTIFIER:self)}), (<IMPL> IDENTIFIER:Printable for IDENTIFIER:Point {(<FUNCTION> IDENTIFIER:to_str <args>(IDENTIFIER:self) <body>STRING:p)})]"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "trait method without self",
+			text: "trait Printable fun to_str() end",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "Method 'to_str' of trait must take self")
+			},
+		},
+		{
+			name: "decorators",
+			text: "@retry(3)\n@memoize\nfun f(x) -> x",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(<FUNCTION> IDENTIFIER:f <decorators>(@(<FUNCTION> IDENTIFIER:retry <args>(INT:3)) @IDENTIFIER:memoize) <args>(IDENTIFIER:x) <body>IDENTIFIER:x)"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "decorated anonymous function",
+			text: "@memoize\nfun(x) -> x",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "Decorated function must have name")
+			},
+		},
+		{
+			name: "macro and quote",
+			text: "macro twice(e) -> quote(unquote(e) + unquote(e))",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(<MACRO> IDENTIFIER:twice <args>(IDENTIFIER:e) <body>(<QUOTE> ((<UNQUOTE> IDENTIFIER:e) PLUS (<UNQUOTE> IDENTIFIER:e))))"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "unquote outside quote",
+			text: "unquote(x)",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+				require.Contains(t, err.Error(), "unquote is only allowed inside quote")
+			},
+		},
+		{
+			name: "docstring",
+			text: "## comment doc\nfun f(x)\n\t\"body doc\"\n\tx\nend\n## g doc\n@memoize\nfun g() -> \"not doc\"",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, &parser.JListNode{}, node)
+
+				elementNodes := node.(*parser.JListNode).ElementNodes
+				require.Equal(t, "body doc", elementNodes[0].(*parser.JFuncDefNode).Doc)
+				require.Equal(t, "g doc", elementNodes[1].(*parser.JFuncDefNode).Doc)
+			},
+		},
+		{
+			name: "newlines inside brackets and after operators",
+			text: "c = {\n\t\"a\": [\n\t\t1,\n\t\t2,\n\t],\n\t\"b\":\n\t\tf(\n\t\t\tx,\n\t\t) +\n\t\t3,\n}\ny = 1",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, &parser.JListNode{}, node)
+
+				resStr := "[(IDENTIFIER:c = {STRING:a: [INT:1, INT:2], STRING:b: ((<FUNCTION> IDENTIFIER:f <args>(IDENTIFIER:x)) PLUS INT:3)}), (IDENTIFIER:y = INT:1)]"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "missing arg between commas across lines",
+			text: "f(1\n\n, 2, , 3)",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+			},
+		},
+		{
+			name: "shell literal",
+			text: `@"ls {dir} {!flags} {{x}} {m['k']}"`,
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, &parser.JShellNode{}, node)
+
+				resStr := `(<SHELL> "ls " {IDENTIFIER:dir} " " {!IDENTIFIER:flags} " {x} " {IDENTIFIER:m[STRING:k]} "")`
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "shell literal keeps ${} and single-quoted text",
+			text: `@"echo ${HOME} \"{dir}\" | awk '{print $1}' {file}"`,
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.IsType(t, &parser.JShellNode{}, node)
+
+				resStr := `(<SHELL> "echo ${HOME} \"" {IDENTIFIER:dir} "\" | awk '{print $1}' " {IDENTIFIER:file} "")`
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "unclosed brace of shell literal",
+			text: `@"ls {dir"`,
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.Error(t, err)
+				require.IsType(t, &common.JInvalidSyntaxError{}, errors.Cause(err))
+			},
+		},
+		{
+			name: "let and const",
+			text: "if a then let x = 1 else const y = 2",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "if (IDENTIFIER:a) {(KEYWORD:let IDENTIFIER:x = INT:1)} else (KEYWORD:const IDENTIFIER:y = INT:2)"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "nonlocal",
+			text: "nonlocal a, b",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "KEYWORD:nonlocal IDENTIFIER:a, IDENTIFIER:b"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "defer",
+			text: "defer close(f)",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "KEYWORD:defer (<FUNCTION> IDENTIFIER:close <args>(IDENTIFIER:f))"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "comprehension",
+			text: "[{k: v for k, v in m if v} for m in ms if m for i in 0..2]",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "[{IDENTIFIER:k: IDENTIFIER:v for IDENTIFIER:k, IDENTIFIER:v in IDENTIFIER:m if IDENTIFIER:v} " +
+					"for IDENTIFIER:m in IDENTIFIER:ms if IDENTIFIER:m for IDENTIFIER:i in (INT:0 DOTDOT INT:2)]"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "membership and identity",
+			text: "a not in b and c is not null or d in e",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(((KEYWORD:not (IDENTIFIER:a KEYWORD:in IDENTIFIER:b)) KEYWORD:and " +
+					"(KEYWORD:not (IDENTIFIER:c KEYWORD:is IDENTIFIER:null))) KEYWORD:or (IDENTIFIER:d KEYWORD:in IDENTIFIER:e))"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "null-safe access",
+			text: "cfg?.db?[\"port\"] ?? f(1).x[0]",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(IDENTIFIER:cfg?.IDENTIFIER:db?[STRING:port] QQ (<FUNCTION> IDENTIFIER:f <args>(INT:1)).IDENTIFIER:x[INT:0])"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "pipe",
+			text: "x |> f(1)\n  |> g",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(<FUNCTION> IDENTIFIER:g <args>((<FUNCTION> IDENTIFIER:f <args>(IDENTIFIER:x INT:1))))"
+				require.Equal(t, resStr, node.String())
+			},
+		},
+		{
+			name: "pipe binds tighter than comparison",
+			text: "xs |> len > 1 and 0..3 |> list == ys",
+			checkResult: func(t *testing.T, node parser.JNode, err error) {
+				t.Helper()
+				require.NoError(t, err)
+				require.NotEmpty(t, node, err)
+
+				resStr := "(((<FUNCTION> IDENTIFIER:len <args>(IDENTIFIER:xs)) GT INT:1) KEYWORD:and ((<FUNCTION> IDENTIFIER:list <args>((INT:0 DOTDOT INT:3))) EE IDENTIFIER:ys))"
+				require.Equal(t, resStr, node.String())
+			},
+		},
 		{
 			name: "Invalid Syntax1",
 			text: "1 + ",
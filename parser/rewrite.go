@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"reflect"
+
+	"github.com/IfanTsai/jirachi/token"
+)
+
+var (
+	nodeType     = reflect.TypeOf((*JNode)(nil)).Elem()
+	baseNodeType = reflect.TypeOf(&JBaseNode{})
+	parserPath   = baseNodeType.Elem().PkgPath()
+)
+
+// RewriteFunc is called with each node of a tree before its children. It returns the node which replaces
+// the given node, and whether the children of the returned node are rewritten too
+type RewriteFunc func(node JNode) (JNode, bool)
+
+// Rewrite returns a copy of the tree of node in which every node is replaced by the result of f,
+// the tree of node is never modified, so that nodes of a parsed program can be shared by several copies
+func Rewrite(node JNode, f RewriteFunc) JNode {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return node
+	}
+
+	node, descend := f(node)
+	if !descend {
+		return node
+	}
+
+	nodeValue := reflect.ValueOf(node).Elem()
+	copyValue := reflect.New(nodeValue.Type())
+	copyValue.Elem().Set(nodeValue)
+	rewriteFields(copyValue.Elem(), f)
+
+	return copyValue.Interface().(JNode)
+}
+
+// Children returns the nodes directly contained by node in the order of its fields
+func Children(node JNode) []JNode {
+	var children []JNode
+
+	Rewrite(node, func(child JNode) (JNode, bool) {
+		if child == node {
+			return child, true
+		}
+
+		children = append(children, child)
+
+		return child, false
+	})
+
+	return children
+}
+
+// rewriteFields rewrites child nodes in fields of struct value, including nodes in slices and arrays
+// and structs of this package such as JCompClause
+func rewriteFields(structValue reflect.Value, f RewriteFunc) {
+	for index := 0; index < structValue.NumField(); index++ {
+		field := structValue.Field(index)
+		if field.Type() == baseNodeType {
+			continue
+		}
+
+		field.Set(rewriteValue(field, f))
+	}
+}
+
+func rewriteValue(value reflect.Value, f RewriteFunc) reflect.Value {
+	valueType := value.Type()
+
+	switch value.Kind() {
+	case reflect.Interface:
+		if valueType != nodeType || value.IsNil() {
+			return value
+		}
+
+		newNode := Rewrite(value.Interface().(JNode), f)
+		if newNode == nil {
+			return reflect.Zero(valueType)
+		}
+
+		return reflect.ValueOf(newNode)
+	case reflect.Pointer:
+		if value.IsNil() || valueType.Elem().PkgPath() != parserPath {
+			return value
+		}
+
+		if valueType.Implements(nodeType) {
+			// a node of concrete type can only be replaced by node of the same type
+			if newNode := Rewrite(value.Interface().(JNode), f); reflect.TypeOf(newNode) == valueType {
+				return reflect.ValueOf(newNode)
+			}
+
+			return value
+		}
+
+		copyValue := reflect.New(valueType.Elem())
+		copyValue.Elem().Set(value.Elem())
+		rewriteFields(copyValue.Elem(), f)
+
+		return copyValue
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+
+		newSlice := reflect.MakeSlice(valueType, value.Len(), value.Len())
+		for index := 0; index < value.Len(); index++ {
+			newSlice.Index(index).Set(rewriteValue(value.Index(index), f))
+		}
+
+		return newSlice
+	case reflect.Array:
+		newArray := reflect.New(valueType).Elem()
+		for index := 0; index < value.Len(); index++ {
+			newArray.Index(index).Set(rewriteValue(value.Index(index), f))
+		}
+
+		return newArray
+	}
+
+	return value
+}
+
+// BoundNames returns names of variables which are assigned, declared or used as loop variable in the tree of node,
+// nodes inside quote and unquote are skipped
+func BoundNames(node JNode) []string {
+	var names []string
+
+	addTokens := func(varTokens ...*token.JToken) {
+		for _, varToken := range varTokens {
+			if name, ok := varToken.Value.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	Rewrite(node, func(node JNode) (JNode, bool) {
+		switch node := node.(type) {
+		case *JQuoteNode, *JUnquoteNode:
+			return node, false
+		case *JVarAssignNode:
+			addTokens(node.Token)
+		case *JVarDeclareNode:
+			addTokens(node.VarToken)
+		case *JVarUnpackAssignNode:
+			addTokens(node.VarTokens...)
+		case *JForExprNode:
+			addTokens(node.Token)
+		case *JForInExprNode:
+			addTokens(node.Token)
+		}
+
+		return node, true
+	})
+
+	return names
+}
+
+// RenameVars returns a copy of the tree of node in which variables are renamed by names,
+// nodes inside quote and unquote are not renamed
+func RenameVars(node JNode, names map[string]string) JNode {
+	rename := func(varToken *token.JToken) *token.JToken {
+		name, ok := varToken.Value.(string)
+		if !ok {
+			return varToken
+		}
+
+		newName, ok := names[name]
+		if !ok {
+			return varToken
+		}
+
+		return token.NewJToken(varToken.Type, newName, varToken.StartPos, varToken.EndPos)
+	}
+
+	renameAll := func(varTokens []*token.JToken) []*token.JToken {
+		newTokens := make([]*token.JToken, len(varTokens))
+		for index, varToken := range varTokens {
+			newTokens[index] = rename(varToken)
+		}
+
+		return newTokens
+	}
+
+	withToken := func(baseNode *JBaseNode) *JBaseNode {
+		newBaseNode := *baseNode
+		newBaseNode.Token = rename(baseNode.Token)
+
+		return &newBaseNode
+	}
+
+	return Rewrite(node, func(node JNode) (JNode, bool) {
+		switch node := node.(type) {
+		case *JQuoteNode, *JUnquoteNode:
+			return node, false
+		case *JVarAccessNode:
+			return &JVarAccessNode{JBaseNode: withToken(node.JBaseNode)}, false
+		case *JVarAssignNode:
+			newNode := *node
+			newNode.JBaseNode = withToken(node.JBaseNode)
+
+			return &newNode, true
+		case *JVarDeclareNode:
+			newNode := *node
+			newNode.VarToken = rename(node.VarToken)
+
+			return &newNode, true
+		case *JScopeDeclareNode:
+			newNode := *node
+			newNode.VarTokens = renameAll(node.VarTokens)
+
+			return &newNode, true
+		case *JVarUnpackAssignNode:
+			newNode := *node
+			newNode.VarTokens = renameAll(node.VarTokens)
+
+			return &newNode, true
+		case *JForExprNode:
+			newNode := *node
+			newNode.JBaseNode = withToken(node.JBaseNode)
+
+			return &newNode, true
+		case *JForInExprNode:
+			newNode := *node
+			newNode.JBaseNode = withToken(node.JBaseNode)
+
+			return &newNode, true
+		}
+
+		return node, true
+	})
+}
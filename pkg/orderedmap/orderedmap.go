@@ -0,0 +1,91 @@
+package orderedmap
+
+import "container/list"
+
+type entry[V any] struct {
+	key   any
+	value V
+}
+
+// OrderedMap is a map which remembers the insertion order of its keys.
+// Updating the value of an existing key does not change its position.
+type OrderedMap[V any] struct {
+	elements map[any]*list.Element
+	order    *list.List
+}
+
+// NewOrderedMap returns an OrderedMap.
+func NewOrderedMap[V any]() *OrderedMap[V] {
+	return &OrderedMap[V]{
+		elements: make(map[any]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Del deletes the object with the given key from m, returns false if the key is not present.
+func (m *OrderedMap[V]) Del(key any) bool {
+	element, ok := m.elements[key]
+	if !ok {
+		return false
+	}
+
+	m.order.Remove(element)
+	delete(m.elements, key)
+
+	return true
+}
+
+// Get gets the object with the given key from m.
+func (m *OrderedMap[V]) Get(key any) (V, bool) {
+	element, ok := m.elements[key]
+	if !ok {
+		var zero V
+
+		return zero, false
+	}
+
+	return element.Value.(*entry[V]).value, true
+}
+
+// Set sets the object into m with the given key.
+func (m *OrderedMap[V]) Set(key any, value V) {
+	if element, ok := m.elements[key]; ok {
+		element.Value.(*entry[V]).value = value
+
+		return
+	}
+
+	m.elements[key] = m.order.PushBack(&entry[V]{key: key, value: value})
+}
+
+// Size returns the size of m.
+func (m *OrderedMap[V]) Size() int {
+	return len(m.elements)
+}
+
+// Range calls f sequentially for each key and value present in the map in insertion order.
+// It is safe to delete the key passed to f while ranging.
+func (m *OrderedMap[V]) Range(f func(key any, value V) bool) {
+	for element := m.order.Front(); element != nil; {
+		next := element.Next()
+
+		e := element.Value.(*entry[V])
+		if !f(e.key, e.value) {
+			break
+		}
+
+		element = next
+	}
+}
+
+// Copy returns a shallow copy of m which keeps the same order.
+func (m *OrderedMap[V]) Copy() *OrderedMap[V] {
+	newMap := NewOrderedMap[V]()
+	m.Range(func(key any, value V) bool {
+		newMap.Set(key, value)
+
+		return true
+	})
+
+	return newMap
+}
@@ -109,14 +109,27 @@ func (m *SafeMap[V]) Size() int {
 }
 
 // Range calls f sequentially for each key and value present in the map.
+// f is called on a snapshot, so it is safe to modify m in f.
 func (m *SafeMap[V]) Range(f func(key any, value V) bool) {
-	validMap := m.dirtyNew
-	if len(m.dirtyOld) > 0 {
-		validMap = m.dirtyOld
+	m.lock.RLock()
+
+	keys := make([]any, 0, len(m.dirtyOld)+len(m.dirtyNew))
+	values := make([]V, 0, len(m.dirtyOld)+len(m.dirtyNew))
+
+	for k, v := range m.dirtyOld {
+		keys = append(keys, k)
+		values = append(values, v)
 	}
 
-	for k, v := range validMap {
-		if !f(k, v) {
+	for k, v := range m.dirtyNew {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+
+	m.lock.RUnlock()
+
+	for i := range keys {
+		if !f(keys[i], values[i]) {
 			break
 		}
 	}
@@ -15,29 +15,37 @@ const (
 	INT        JTokenType = "INT"
 	FLOAT      JTokenType = "FLOAT"
 	STRING     JTokenType = "STRING"
+	BYTES      JTokenType = "BYTES"
 	IDENTIFIER JTokenType = "IDENTIFIER"
 	KEYWORD    JTokenType = "KEYWORD"
-	PLUS       JTokenType = "PLUS"    // +
-	MINUS      JTokenType = "MINUS"   // -
-	MUL        JTokenType = "MUL"     // *
-	DIV        JTokenType = "DIV"     // /
-	POW        JTokenType = "POW"     // ^
-	EQ         JTokenType = "EQ"      // =
-	LPAREN     JTokenType = "LPAREN"  // (
-	RPAREN     JTokenType = "RPAREN"  // )
-	LSQUARE    JTokenType = "LSQUARE" // [
-	RSQUARE    JTokenType = "RSQUARE" // ]
-	LBRACE     JTokenType = "LBRACE"  // {
-	RBRACE     JTokenType = "RBRACE"  // }
-	COLON      JTokenType = "COLON"   // :
-	EE         JTokenType = "EE"      // ==
-	NE         JTokenType = "NE"      // !=
-	LT         JTokenType = "LT"      // <
-	GT         JTokenType = "GT"      // >
-	LTE        JTokenType = "LTE"     // <=
-	GTE        JTokenType = "GTE"     // >=
-	COMMA      JTokenType = "COMMA"   // ,
-	ARROW      JTokenType = "ARROW"   // ->
+	PLUS       JTokenType = "PLUS"     // +
+	MINUS      JTokenType = "MINUS"    // -
+	MUL        JTokenType = "MUL"      // *
+	DIV        JTokenType = "DIV"      // /
+	POW        JTokenType = "POW"      // ^
+	EQ         JTokenType = "EQ"       // =
+	LPAREN     JTokenType = "LPAREN"   // (
+	RPAREN     JTokenType = "RPAREN"   // )
+	LSQUARE    JTokenType = "LSQUARE"  // [
+	RSQUARE    JTokenType = "RSQUARE"  // ]
+	LBRACE     JTokenType = "LBRACE"   // {
+	RBRACE     JTokenType = "RBRACE"   // }
+	COLON      JTokenType = "COLON"    // :
+	EE         JTokenType = "EE"       // ==
+	NE         JTokenType = "NE"       // !=
+	LT         JTokenType = "LT"       // <
+	GT         JTokenType = "GT"       // >
+	LTE        JTokenType = "LTE"      // <=
+	GTE        JTokenType = "GTE"      // >=
+	COMMA      JTokenType = "COMMA"    // ,
+	ARROW      JTokenType = "ARROW"    // ->
+	DOT        JTokenType = "DOT"      // .
+	DOTDOT     JTokenType = "DOTDOT"   // ..
+	QDOT       JTokenType = "QDOT"     // ?.
+	QLSQUARE   JTokenType = "QLSQUARE" // ?[
+	QQ         JTokenType = "QQ"       // ??
+	PIPE       JTokenType = "PIPE"     // |>
+	COMMENT    JTokenType = "COMMENT"  // # ... or #[ ... ]#, only made by lexer keeping comments
 	NEWLINE    JTokenType = "NEWLINE"
 	EOF        JTokenType = "EOF"
 )
@@ -52,6 +60,8 @@ const (
 	ELSE     = "else"
 	FOR      = "for"
 	TO       = "to"
+	IN       = "in"
+	IS       = "is"
 	STEP     = "step"
 	WHILE    = "while"
 	FUN      = "fun"
@@ -59,6 +69,17 @@ const (
 	RETURN   = "return"
 	BREAK    = "break"
 	CONTINUE = "continue"
+	DEFER    = "defer"
+	LET      = "let"
+	CONST    = "const"
+	GLOBAL   = "global"
+	NONLOCAL = "nonlocal"
+	ENUM     = "enum"
+	TRAIT    = "trait"
+	IMPL     = "impl"
+	MACRO    = "macro"
+	QUOTE    = "quote"
+	UNQUOTE  = "unquote"
 )
 
 var KEYWORDS = set.NewSet(
@@ -71,12 +92,25 @@ var KEYWORDS = set.NewSet(
 	ELSE,
 	FOR,
 	TO,
+	IN,
+	IS,
 	STEP,
 	WHILE,
 	FUN,
 	END,
 	RETURN,
 	BREAK,
+	DEFER,
+	LET,
+	CONST,
+	GLOBAL,
+	NONLOCAL,
+	ENUM,
+	TRAIT,
+	IMPL,
+	MACRO,
+	QUOTE,
+	UNQUOTE,
 	CONTINUE,
 )
 
@@ -85,6 +119,7 @@ type JToken struct {
 	Value    interface{}
 	StartPos *common.JPosition
 	EndPos   *common.JPosition
+	Doc      string // text of ## doc comments on the lines right before the token
 }
 
 func NewJToken(tokenType JTokenType, value interface{}, startPos, endPos *common.JPosition) *JToken {